@@ -17,18 +17,30 @@ limitations under the License.
 package main
 
 import (
+	"archive/zip"
 	"bytes"
 	"encoding/json"
+	"flag"
 	"fmt"
+	"go/build"
+	"go/parser"
+	"go/token"
 	"io"
 	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/google/go-cmp/cmp" //nolint:depguard
+	"golang.org/x/mod/modfile"
+	modulepkg "golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
 )
 
 type Unwanted struct {
@@ -43,6 +55,17 @@ type UnwantedSpec struct {
 	UnwantedModules map[string]string `json:"unwantedModules"`
 	// module names that should never be updated from their current version, mapped to a struct with version and reason
 	PinnedModules map[string]PinnedModule `json:"pinnedModules"`
+	// TargetPlatforms lists the GOOS/GOARCH pairs (e.g. "linux/amd64") used
+	// when determining whether a third-party module actually imports an
+	// unwanted dependency for some platform we care about. Defaults to
+	// linux/amd64 and linux/arm64 if empty.
+	TargetPlatforms []string `json:"targetPlatforms,omitempty"`
+	// AllowlistExpiry maps an unwanted module name to a "YYYY-MM-DD" date
+	// after which its entry in unwantedModules must be reviewed again: the
+	// check fails once that date has passed, even if nothing else about the
+	// dependency has changed. This keeps long-lived exceptions from being
+	// forgotten.
+	AllowlistExpiry map[string]string `json:"allowlistExpiry,omitempty"`
 }
 
 type PinnedModule struct {
@@ -174,6 +197,54 @@ func difference(a, b []string) ([]string, []string) {
 	return aMinusBList, bMinusAList
 }
 
+// intersectSorted returns the sorted set of elements present in both a and b.
+func intersectSorted(a, b []string) []string {
+	bSet := map[string]bool{}
+	for _, x := range b {
+		bSet[x] = true
+	}
+	var out []string
+	for _, x := range a {
+		if bSet[x] {
+			out = append(out, x)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// allowlistExpiryLayout is the expected date format for spec.allowlistExpiry
+// entries, chosen to be readable and unambiguous in a hand-edited JSON file.
+const allowlistExpiryLayout = "2006-01-02"
+
+// checkAllowlistExpiry reports, via findings, any module in expiry whose
+// allowlist entry has passed its review date as of now, or whose date isn't
+// a valid allowlistExpiryLayout date. It returns true if anything was
+// reported, so callers can fold it into their overall needUpdate decision.
+func checkAllowlistExpiry(expiry map[string]string, now time.Time, findings *[]finding) bool {
+	names := make([]string, 0, len(expiry))
+	for name := range expiry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	reported := false
+	for _, name := range names {
+		dateStr := expiry[name]
+		deadline, err := time.Parse(allowlistExpiryLayout, dateStr)
+		if err != nil {
+			recordFinding(findings, "error", "allowlist-expiry-invalid", fmt.Sprintf("spec.allowlistExpiry[%q] = %q is not a valid date (want %s)", name, dateStr, allowlistExpiryLayout))
+			reported = true
+			continue
+		}
+		if now.After(deadline) {
+			recordFinding(findings, "warning", "allowlist-expired", fmt.Sprintf("Unwanted module %q has an allowlist expiry of %s, which has passed. Re-review whether it's still needed and bump spec.allowlistExpiry[%q], or remove the module's allowlisting entirely.", name, dateStr, name))
+			reported = true
+		}
+	}
+	return reported
+}
+
 type module struct {
 	name    string
 	version string
@@ -184,6 +255,31 @@ type targetPlatform struct {
 	goarch string
 }
 
+// defaultTargetPlatforms are the GOOS/GOARCH pairs checked when
+// dependencies.json doesn't specify spec.targetPlatforms.
+var defaultTargetPlatforms = []targetPlatform{
+	{goos: "linux", goarch: "amd64"},
+	{goos: "linux", goarch: "arm64"},
+}
+
+// parseTargetPlatforms converts the "GOOS/GOARCH" strings from
+// spec.targetPlatforms into targetPlatform values, falling back to
+// defaultTargetPlatforms if specs is empty.
+func parseTargetPlatforms(specs []string) ([]targetPlatform, error) {
+	if len(specs) == 0 {
+		return defaultTargetPlatforms, nil
+	}
+	targets := make([]targetPlatform, 0, len(specs))
+	for _, spec := range specs {
+		goos, goarch, ok := strings.Cut(spec, "/")
+		if !ok || goos == "" || goarch == "" {
+			return nil, fmt.Errorf("invalid targetPlatform %q: must be GOOS/GOARCH", spec)
+		}
+		targets = append(targets, targetPlatform{goos: goos, goarch: goarch})
+	}
+	return targets, nil
+}
+
 func (m module) String() string {
 	if len(m.version) == 0 {
 		return m.name
@@ -199,238 +295,412 @@ func parseModule(s string) module {
 	return module{name: parts[0], version: parts[1]}
 }
 
-func goListImportsByTarget(dir string, targets []targetPlatform) (map[string]bool, error) {
-	imports := map[string]bool{}
-	var errs []string
-	successes := 0
+// workspaceModuleDirs returns a map from module name to its directory
+// (relative to root) for every module declared via a `use` directive in a
+// go.work file at root. It returns a nil map and no error if root has no
+// go.work file, which is the common single-module case.
+func workspaceModuleDirs(root string) (map[string]string, error) {
+	data, err := os.ReadFile(filepath.Join(root, "go.work"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
 
-	for _, target := range targets {
-		env := []string{
-			"GOOS=" + target.goos,
-			"GOARCH=" + target.goarch,
-			"CGO_ENABLED=0",
+	dirs := map[string]string{}
+	inUseBlock := false
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		var dir string
+		switch {
+		case inUseBlock && line == ")":
+			inUseBlock = false
+			continue
+		case inUseBlock && line != "" && !strings.HasPrefix(line, "//"):
+			dir = line
+		case line == "use (" || line == "use(":
+			inUseBlock = true
+			continue
+		case strings.HasPrefix(line, "use "):
+			dir = strings.TrimSpace(strings.TrimPrefix(line, "use"))
+		default:
+			continue
 		}
-		output, err := runCommandInDirWithEnv(dir, env, []string{"go", "list", "-buildvcs=false", "-f", "{{range .Imports}}{{.}}\n{{end}}", "./..."})
+		dir = strings.Trim(dir, `"`)
+		modPath := filepath.Join(root, dir, "go.mod")
+		modData, err := os.ReadFile(modPath)
 		if err != nil {
-			errs = append(errs, fmt.Sprintf("%s/%s: %v", target.goos, target.goarch, err))
-			continue
+			return nil, fmt.Errorf("reading %s: %w", modPath, err)
 		}
-		successes++
-		for _, imp := range strings.Split(strings.TrimSpace(output), "\n") {
-			if imp == "" {
-				continue
-			}
-			imports[imp] = true
+		name := moduleNameFromGoMod(string(modData))
+		if name == "" {
+			continue
 		}
+		dirs[name] = dir
 	}
+	return dirs, nil
+}
 
-	if successes == 0 {
-		return nil, fmt.Errorf("go list failed for all target platforms: %s", strings.Join(errs, "; "))
+// moduleNameFromGoMod extracts the module path from the `module` directive of
+// a go.mod file's content.
+func moduleNameFromGoMod(goModContent string) string {
+	for _, line := range strings.Split(goModContent, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "module ") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "module"))
+		}
 	}
-
-	return imports, nil
+	return ""
 }
 
-// buildModuleImportsMap downloads each module and runs `go list` from within
-// the module directory to determine which modules it actually imports.
-// Returns a map of module name -> set of module names it imports.
-func buildModuleImportsMap(modulesToCheck []string, moduleVersions map[string]string) (map[string]map[string]bool, error) {
-	if len(modulesToCheck) == 0 {
-		return make(map[string]map[string]bool), nil
+// moduleDir returns the directory (relative to the repo root) containing the
+// go.mod of the named module, given the workspace module dirs discovered by
+// workspaceModuleDirs (which may be nil).
+func moduleDir(name string, workspaceDirs map[string]string) string {
+	if dir, ok := workspaceDirs[name]; ok {
+		return dir
 	}
-
-	targets := []targetPlatform{
-		{goos: "linux", goarch: "amd64"},
-		{goos: "linux", goarch: "arm64"},
+	if name == "k8s.io/kubernetes" {
+		return ""
 	}
+	return "staging/src/" + name
+}
 
-	moduleImports := make(map[string]map[string]bool)
-	for _, mod := range modulesToCheck {
-		version := moduleVersions[mod]
-		if version == "" || version == "v0.0.0" {
-			continue
-		}
-		// Download the module and get its directory using go mod download -json
-		output, err := runCommand("go", "mod", "download", "-json", mod+"@"+version)
-		if err != nil {
-			// Module might not be downloadable, skip it
-			continue
+// goModGoVersion extracts the version from the `go` directive of a go.mod
+// file's content, or "" if none is found.
+func goModGoVersion(goModContent string) string {
+	for _, line := range strings.Split(goModContent, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "go ") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "go"))
 		}
+	}
+	return ""
+}
 
-		// Parse the JSON to get the Dir field
-		var downloadInfo struct {
-			Dir string `json:"Dir"`
-		}
-		if err := json.Unmarshal([]byte(output), &downloadInfo); err != nil {
-			continue
-		}
-		if downloadInfo.Dir == "" {
-			continue
-		}
+// isPrunedGraph reports whether goVersion (a go.mod "go" directive value) is
+// high enough (go 1.17+) that `go mod graph` run against that module applies
+// module graph pruning (https://go.dev/ref/mod#graph-pruning). A pruned graph
+// only includes the requirements needed to build and test packages actually
+// reachable from the main module's build list, and can omit requirements on
+// modules that aren't imported by anything - including ones we'd otherwise
+// want to flag as unwanted.
+func isPrunedGraph(goVersion string) bool {
+	major, minor, ok := parseGoVersion(goVersion)
+	if !ok {
+		return false
+	}
+	return major > 1 || (major == 1 && minor >= 17)
+}
 
-		// Run go list across supported target platforms and union the imports.
-		// {{.Imports}} gives direct imports from non-test files.
-		// -buildvcs=false is needed because module cache is read-only without VCS info.
-		importPaths, err := goListImportsByTarget(downloadInfo.Dir, targets)
-		if err != nil {
-			// Module might have replace directives with relative paths that don't work.
-			// Try copying to a temp dir and removing replace directives.
-			importPaths, err = runGoListWithoutReplace(downloadInfo.Dir, targets)
-			if err != nil {
-				// Still failed, skip it
-				continue
-			}
-		}
+// parseGoVersion parses the major and minor components of a go.mod "go"
+// directive value such as "1.21" or "1.21.0".
+func parseGoVersion(v string) (major, minor int, ok bool) {
+	parts := strings.SplitN(v, ".", 3)
+	if len(parts) < 2 {
+		return 0, 0, false
+	}
+	major, err1 := strconv.Atoi(parts[0])
+	minor, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil {
+		return 0, 0, false
+	}
+	return major, minor, true
+}
 
-		moduleImports[mod] = make(map[string]bool)
-		for imp := range importPaths {
-			// Extract module from import path by finding longest matching module
-			impModule := findModuleForPackage(imp, moduleVersions)
-			if impModule != "" && impModule != mod {
-				moduleImports[mod][impModule] = true
-			}
+// downgradeGoDirective rewrites goModContent's `go` directive to "1.16", the
+// highest version below the module graph pruning threshold, if it's high
+// enough to trigger pruning. It returns goModContent unchanged otherwise.
+func downgradeGoDirective(goModContent string) string {
+	lines := strings.Split(goModContent, "\n")
+	changed := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "go ") && isPrunedGraph(strings.TrimSpace(strings.TrimPrefix(trimmed, "go"))) {
+			lines[i] = "go 1.16"
+			changed = true
 		}
 	}
-
-	return moduleImports, nil
+	if !changed {
+		return goModContent
+	}
+	return strings.Join(lines, "\n")
 }
 
-// runGoListWithoutReplace copies a module to a temp directory, removes replace
-// directives from go.mod, and runs go list. This handles modules like etcd that
-// use replace directives with relative paths that don't work when downloaded alone.
-func runGoListWithoutReplace(moduleDir string, targets []targetPlatform) (map[string]bool, error) {
-	// Create temp directory
-	tmpDir, err := os.MkdirTemp("", "depverifier-*")
+// directRequiresFromGoMod returns the set of module paths directly required
+// by a go.mod file, i.e. requirements without a "// indirect" comment. This
+// is an in-process equivalent of what
+// `go list -m -f '{{if not .Indirect}}{{if not .Main}}{{.Path}}{{end}}{{end}}' all`
+// used to report for the main module, without shelling out to the go command
+// for each one: the direct/indirect split it reports for the main module is
+// exactly what's already recorded in the main module's own go.mod.
+func directRequiresFromGoMod(goModPath string, goModContent []byte) (map[string]bool, error) {
+	f, err := modfile.Parse(goModPath, goModContent, nil)
 	if err != nil {
 		return nil, err
 	}
-	defer os.RemoveAll(tmpDir)
+	direct := map[string]bool{}
+	for _, r := range f.Require {
+		if !r.Indirect {
+			direct[r.Mod.Path] = true
+		}
+	}
+	return direct, nil
+}
 
-	// Copy module contents to temp dir.
-	if err := copyDirectoryContents(moduleDir, tmpDir); err != nil {
-		return nil, err
+// fullModGraph returns the unpruned `go mod graph` output for the module in
+// dir, by running the command against a temporarily-downgraded copy of its
+// go.mod. It returns ("", nil) if the module's graph isn't pruned in the
+// first place, since there's nothing to recover.
+func fullModGraph(dir string) (string, error) {
+	goModPath := filepath.Join(dir, "go.mod")
+	original, err := os.ReadFile(goModPath)
+	if err != nil {
+		return "", err
 	}
 
-	// Make go.mod and go.sum writable (module cache files are read-only)
-	goModPath := tmpDir + "/go.mod"
-	if err := os.Chmod(goModPath, 0644); err != nil {
-		return nil, err
+	lowered := downgradeGoDirective(string(original))
+	if lowered == string(original) {
+		return "", nil
 	}
-	goSumPath := tmpDir + "/go.sum"
-	if _, err := os.Stat(goSumPath); err == nil {
-		if err := os.Chmod(goSumPath, 0644); err != nil {
-			return nil, err
-		}
+
+	if err := os.WriteFile(goModPath, []byte(lowered), 0644); err != nil {
+		return "", err
 	}
-	goModContent, err := os.ReadFile(goModPath)
+	defer os.WriteFile(goModPath, original, 0644)
+
+	return runCommandInDir(dir, []string{"go", "mod", "graph"})
+}
+
+// ModuleImportIndex resolves which other modules a module actually imports
+// by reading its go.mod and source straight out of the local module cache
+// (via golang.org/x/mod/modfile and golang.org/x/mod/module, plus go/parser
+// over its cached .zip), instead of shelling out to `go mod download` and
+// `go list` - or, when replace directives got in the way, copying the
+// module to a temp dir and running `go mod tidy` - for every candidate
+// module.
+type ModuleImportIndex struct {
+	cacheDir string
+	targets  []targetPlatform
+
+	mu      sync.Mutex
+	imports map[string]map[string]bool
+}
+
+// NewModuleImportIndex returns a ModuleImportIndex that resolves modules out
+// of the local module cache (GOMODCACHE, or GOPATH/pkg/mod if unset) for the
+// given target platforms.
+func NewModuleImportIndex(targets []targetPlatform) (*ModuleImportIndex, error) {
+	cacheDir, err := moduleCacheDir()
 	if err != nil {
 		return nil, err
 	}
+	return &ModuleImportIndex{
+		cacheDir: cacheDir,
+		targets:  targets,
+		imports:  map[string]map[string]bool{},
+	}, nil
+}
 
-	// Remove replace blocks and single replace directives
-	lines := strings.Split(string(goModContent), "\n")
-	var newLines []string
-	inReplaceBlock := false
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-		if strings.HasPrefix(trimmed, "replace (") || strings.HasPrefix(trimmed, "replace(") {
-			inReplaceBlock = true
-			continue
-		}
-		if inReplaceBlock {
-			if trimmed == ")" {
-				inReplaceBlock = false
-			}
-			continue
-		}
-		if strings.HasPrefix(trimmed, "replace ") {
-			continue
+// moduleCacheDir returns the local module cache directory, preferring
+// GOMODCACHE and falling back to GOPATH/pkg/mod.
+func moduleCacheDir() (string, error) {
+	if dir := os.Getenv("GOMODCACHE"); dir != "" {
+		return dir, nil
+	}
+	gopath := os.Getenv("GOPATH")
+	if gopath == "" {
+		output, err := runCommand("go", "env", "GOPATH")
+		if err != nil {
+			return "", err
 		}
-		newLines = append(newLines, line)
+		gopath = strings.TrimSpace(output)
 	}
+	return filepath.Join(gopath, "pkg", "mod"), nil
+}
 
-	if err := os.WriteFile(goModPath, []byte(strings.Join(newLines, "\n")), 0644); err != nil {
-		return nil, err
+// Add resolves mod@version's direct package imports from the module cache
+// and records which of the modules named in moduleVersions it imports. It's
+// a no-op, not an error, if mod@version isn't present in the cache (e.g. it
+// was never reached by `go mod graph`), matching the old buildModuleImportsMap's
+// "skip it" behavior for undownloadable modules.
+func (idx *ModuleImportIndex) Add(mod, version string, moduleVersions map[string]string) error {
+	imports, err := idx.resolveImports(mod, version)
+	if err != nil || imports == nil {
+		return err
 	}
 
-	// Update go.sum after removing replace directives
-	if _, err := runCommandInDir(tmpDir, []string{"go", "mod", "tidy"}); err != nil {
-		return nil, err
+	resolved := make(map[string]bool, len(imports))
+	for imp := range imports {
+		if owner := findModuleForPackage(imp, moduleVersions); owner != "" && owner != mod {
+			resolved[owner] = true
+		}
 	}
 
-	// Run go list in the temp directory
-	return goListImportsByTarget(tmpDir, targets)
+	idx.mu.Lock()
+	idx.imports[mod] = resolved
+	idx.mu.Unlock()
+	return nil
 }
 
-func copyDirectoryContents(srcRoot, dstRoot string) error {
-	return filepath.WalkDir(srcRoot, func(srcPath string, d os.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
+// Imports returns the set of module names mod was found to import, or nil if
+// mod hasn't been added, or resolving it failed or was skipped.
+func (idx *ModuleImportIndex) Imports(mod string) map[string]bool {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	return idx.imports[mod]
+}
 
-		relPath, err := filepath.Rel(srcRoot, srcPath)
-		if err != nil {
-			return err
+// resolveImports returns the import paths referenced by mod@version's
+// non-test .go files that match at least one of idx.targets, read directly
+// from GOMODCACHE/cache/download/<mod>/@v/<version>.{mod,zip}. It returns a
+// nil map and no error if mod@version isn't in the cache.
+func (idx *ModuleImportIndex) resolveImports(mod, version string) (map[string]bool, error) {
+	escapedPath, err := modulepkg.EscapePath(mod)
+	if err != nil {
+		return nil, nil
+	}
+	escapedVersion, err := modulepkg.EscapeVersion(version)
+	if err != nil {
+		return nil, nil
+	}
+
+	verDir := filepath.Join(idx.cacheDir, "cache", "download", escapedPath, "@v")
+	goModPath := filepath.Join(verDir, escapedVersion+".mod")
+	goModContent, err := os.ReadFile(goModPath)
+	if err != nil {
+		return nil, nil
+	}
+	if _, err := modfile.Parse(goModPath, goModContent, nil); err != nil {
+		return nil, nil
+	}
+
+	zr, err := zip.OpenReader(filepath.Join(verDir, escapedVersion+".zip"))
+	if err != nil {
+		return nil, nil
+	}
+	defer zr.Close()
+
+	prefix := mod + "@" + version + "/"
+	imports := map[string]bool{}
+	for _, zf := range zr.File {
+		if !strings.HasSuffix(zf.Name, ".go") || strings.HasSuffix(zf.Name, "_test.go") {
+			continue
 		}
-		if relPath == "." {
-			return nil
+		relPath := strings.TrimPrefix(zf.Name, prefix)
+		if relPath == zf.Name || strings.Contains(relPath, "/testdata/") {
+			continue
 		}
 
-		dstPath := filepath.Join(dstRoot, relPath)
-		info, err := d.Info()
+		content, err := readZipFile(zf)
+		if err != nil || !idx.matchesAnyTarget(relPath, content) {
+			continue
+		}
+		fileImports, err := importsFromSource(relPath, content)
 		if err != nil {
-			return err
+			continue
 		}
-
-		if d.IsDir() {
-			// Module cache directories are often read-only. Make copied dirs writable
-			// so fallback steps can create nested files and run go commands.
-			mode := info.Mode().Perm()
-			mode |= 0700
-			return os.MkdirAll(dstPath, mode)
+		for _, imp := range fileImports {
+			imports[imp] = true
 		}
+	}
+	return imports, nil
+}
 
-		if info.Mode()&os.ModeSymlink != 0 {
-			target, err := os.Readlink(srcPath)
-			if err != nil {
-				return err
-			}
-			if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
-				return err
-			}
-			return os.Symlink(target, dstPath)
+// matchesAnyTarget reports whether relPath, with the given file content,
+// would be compiled for at least one of idx.targets, honoring Go's standard
+// $GOOS/$GOARCH filename suffixes and //go:build constraints.
+func (idx *ModuleImportIndex) matchesAnyTarget(relPath string, content []byte) bool {
+	for _, target := range idx.targets {
+		bctx := build.Default
+		bctx.GOOS = target.goos
+		bctx.GOARCH = target.goarch
+		bctx.CgoEnabled = false
+		bctx.OpenFile = func(string) (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(content)), nil
 		}
-
-		if !info.Mode().IsRegular() {
-			return nil
+		if match, err := bctx.MatchFile(filepath.Dir(relPath), filepath.Base(relPath)); err == nil && match {
+			return true
 		}
+	}
+	return false
+}
 
-		return copyRegularFile(srcPath, dstPath, info.Mode().Perm())
-	})
+func readZipFile(zf *zip.File) ([]byte, error) {
+	rc, err := zf.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
 }
 
-func copyRegularFile(srcPath, dstPath string, mode os.FileMode) error {
-	srcFile, err := os.Open(srcPath)
+// importsFromSource returns the import paths declared by a single .go file,
+// without type-checking or resolving them.
+func importsFromSource(relPath string, content []byte) ([]string, error) {
+	f, err := parser.ParseFile(token.NewFileSet(), relPath, content, parser.ImportsOnly)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	imports := make([]string, 0, len(f.Imports))
+	for _, imp := range f.Imports {
+		path, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		imports = append(imports, path)
 	}
-	defer srcFile.Close()
+	return imports, nil
+}
 
-	if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
-		return err
+// buildModuleImportsMap resolves, for each module in modulesToCheck, which
+// other known modules (per moduleVersions) it actually imports, using a
+// ModuleImportIndex parallelized across a worker pool bounded by GOMAXPROCS.
+func buildModuleImportsMap(modulesToCheck []string, moduleVersions map[string]string, targets []targetPlatform) (map[string]map[string]bool, error) {
+	if len(modulesToCheck) == 0 {
+		return make(map[string]map[string]bool), nil
 	}
 
-	// Source files in module cache are typically 0444. Copy as writable so
-	// follow-up commands (like go mod tidy) can update module files as needed.
-	writableMode := mode | 0200
-	dstFile, err := os.OpenFile(dstPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, writableMode)
+	idx, err := NewModuleImportIndex(targets)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	workers := runtime.GOMAXPROCS(0)
+	if workers > len(modulesToCheck) {
+		workers = len(modulesToCheck)
+	}
+	modCh := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for mod := range modCh {
+				version := moduleVersions[mod]
+				if version == "" || version == "v0.0.0" {
+					continue
+				}
+				if err := idx.Add(mod, version, moduleVersions); err != nil {
+					log.Printf("Warning: failed to resolve imports for %s@%s: %s", mod, version, err)
+				}
+			}
+		}()
+	}
+	for _, mod := range modulesToCheck {
+		modCh <- mod
 	}
-	defer dstFile.Close()
+	close(modCh)
+	wg.Wait()
 
-	_, err = io.Copy(dstFile, srcFile)
-	return err
+	moduleImports := make(map[string]map[string]bool, len(modulesToCheck))
+	for _, mod := range modulesToCheck {
+		if imports := idx.Imports(mod); imports != nil {
+			moduleImports[mod] = imports
+		}
+	}
+	return moduleImports, nil
 }
 
 // findModuleForPackage finds the module that owns a given package path.
@@ -465,20 +735,107 @@ func isDirectImporter(moduleImports map[string]map[string]bool, moduleName, unwa
 
 // option1: dependencyverifier dependencies.json
 // it will run `go mod graph` and check it.
-func main() {
-	var modeGraphStr string
-	var err error
-	if len(os.Args) == 2 {
-		// run `go mod graph`
-		modeGraphStr, err = runCommand("go", "mod", "graph")
+// buildModuleGraph runs `go mod graph` and assembles the main modules, the
+// from->[]to module graph, and the effective (MVS-selected) version of every
+// module, folding in go.work workspace members and recovering edges hidden by
+// go 1.17+ module graph pruning along the way.
+func buildModuleGraph() (mainModules []module, moduleGraph map[module][]module, workspaceDirs map[string]string, effectiveVersions map[string]module, err error) {
+	// run `go mod graph`
+	modeGraphStr, err := runCommand("go", "mod", "graph")
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("running 'go mod graph': %w", err)
+	}
+
+	// convert from `go mod graph` to main module and map of from->[]to references
+	mainModules, moduleGraph = convertToMap(modeGraphStr)
+
+	// If this repo is a go.work workspace, `go mod graph` already reports the
+	// combined graph across all workspace modules, but a module that has no
+	// dependency edges of its own would never appear as a "from" node in that
+	// output. Fold in every workspace member explicitly so it's still treated
+	// as a main module.
+	workspaceDirs, err = workspaceModuleDirs(".")
+	if err != nil {
+		return nil, nil, nil, nil, fmt.Errorf("reading go.work: %w", err)
+	}
+	for name := range workspaceDirs {
+		m := module{name: name}
+		if !moduleInSlice(m, mainModules, false) {
+			mainModules = append(mainModules, m)
+		}
+	}
+
+	// Module graph pruning (go 1.17+) can hide a main module's requirement on
+	// an unwanted module if nothing in the main module's build list actually
+	// imports it. Recover those hidden edges by reading the unpruned graph
+	// for each pruned main module and merging in any requirements it reveals.
+	for _, mainModule := range mainModules {
+		dir := moduleDir(mainModule.name, workspaceDirs)
+		fullGraphStr, err := fullModGraph(dir)
 		if err != nil {
-			log.Fatalf("Error running 'go mod graph': %s", err)
+			log.Printf("Warning: failed to compute unpruned module graph for %s: %s", mainModule.name, err)
+			continue
+		}
+		if fullGraphStr == "" {
+			continue
 		}
-	} else {
-		log.Fatalf("Usage: %s dependencies.json", os.Args[0])
+		_, fullGraph := convertToMap(fullGraphStr)
+		mergeGraphEdges(moduleGraph, fullGraph)
 	}
 
-	dependenciesJSONPath := string(os.Args[1])
+	// gather the effective versions by looking at the versions required by the main modules
+	effectiveVersions = map[string]module{}
+	for _, mainModule := range mainModules {
+		for _, override := range moduleGraph[mainModule] {
+			if _, ok := effectiveVersions[override.name]; !ok {
+				effectiveVersions[override.name] = override
+			}
+		}
+	}
+
+	return mainModules, moduleGraph, workspaceDirs, effectiveVersions, nil
+}
+
+// mergeGraphEdges adds every edge in additional that isn't already present
+// in graph (matching on exact version) to graph, in place. It's used to
+// recover requirement edges hidden by go 1.17+ module graph pruning, once
+// the unpruned graph for a main module has been read separately.
+func mergeGraphEdges(graph map[module][]module, additional map[module][]module) {
+	for from, tos := range additional {
+		for _, to := range tos {
+			if !moduleInSlice(to, graph[from], true) {
+				graph[from] = append(graph[from], to)
+			}
+		}
+	}
+}
+
+// reportFmt is the --format flag value for the default (status check) mode.
+var reportFmt string
+
+func main() {
+	if len(os.Args) >= 2 && os.Args[1] == "why" {
+		runWhy(os.Args[2:])
+		return
+	}
+	if len(os.Args) >= 2 && os.Args[1] == "sbom" {
+		runSBOM(os.Args[2:])
+		return
+	}
+	if len(os.Args) >= 2 && os.Args[1] == "suggest" {
+		runSuggest(os.Args[2:])
+		return
+	}
+
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	fs.StringVar(&reportFmt, "format", "text", `Report format for the unwanted-references status check: "text", "json", or "sarif"`)
+	fs.Parse(os.Args[1:]) //nolint:errcheck
+
+	if fs.NArg() != 1 {
+		log.Fatalf("Usage: %s [--format text|json|sarif] dependencies.json\n       %s why <module-path>\n       %s sbom <spdx|cyclonedx>\n       %s suggest <unwanted-module> <referencer-module>", os.Args[0], os.Args[0], os.Args[0], os.Args[0])
+	}
+
+	dependenciesJSONPath := fs.Arg(0)
 	dependencies, err := readFile(dependenciesJSONPath)
 	if err != nil {
 		log.Fatalf("Error reading dependencies file %s: %s", dependencies, err)
@@ -492,33 +849,24 @@ func main() {
 		log.Fatalf("Error reading dependencies file %s: %s", dependenciesJSONPath, err)
 	}
 
-	// convert from `go mod graph` to main module and map of from->[]to references
-	mainModules, moduleGraph := convertToMap(modeGraphStr)
+	mainModules, moduleGraph, workspaceDirs, effectiveVersions, err := buildModuleGraph()
+	if err != nil {
+		log.Fatalf("Error building module graph: %s", err)
+	}
 
 	directDependencies := map[string]map[string]bool{}
 	for _, mainModule := range mainModules {
-		dir := ""
-		if mainModule.name != "k8s.io/kubernetes" {
-			dir = "staging/src/" + mainModule.name
-		}
-		listOutput, err := runCommandInDir(dir, []string{"go", "list", "-m", "-f", "{{if not .Indirect}}{{if not .Main}}{{.Path}}{{end}}{{end}}", "all"})
+		dir := moduleDir(mainModule.name, workspaceDirs)
+		goModPath := filepath.Join(dir, "go.mod")
+		goModContent, err := os.ReadFile(goModPath)
 		if err != nil {
-			log.Fatalf("Error running 'go list' for %s: %s", mainModule.name, err)
-		}
-		directDependencies[mainModule.name] = map[string]bool{}
-		for _, directDependency := range strings.Split(listOutput, "\n") {
-			directDependencies[mainModule.name][directDependency] = true
+			log.Fatalf("Error reading go.mod for %s: %s", mainModule.name, err)
 		}
-	}
-
-	// gather the effective versions by looking at the versions required by the main modules
-	effectiveVersions := map[string]module{}
-	for _, mainModule := range mainModules {
-		for _, override := range moduleGraph[mainModule] {
-			if _, ok := effectiveVersions[override.name]; !ok {
-				effectiveVersions[override.name] = override
-			}
+		direct, err := directRequiresFromGoMod(goModPath, goModContent)
+		if err != nil {
+			log.Fatalf("Error parsing go.mod for %s: %s", mainModule.name, err)
 		}
+		directDependencies[mainModule.name] = direct
 	}
 
 	// Convert effectiveVersions to simple map[string]string for module versions
@@ -601,8 +949,13 @@ func main() {
 		modulesToCheckList = append(modulesToCheckList, mod)
 	}
 
+	targetPlatforms, err := parseTargetPlatforms(configFromFile.Spec.TargetPlatforms)
+	if err != nil {
+		log.Fatalf("Error parsing spec.targetPlatforms: %s", err)
+	}
+
 	// Build module imports map using `go list -deps` for accurate detection
-	moduleImports, err := buildModuleImportsMap(modulesToCheckList, moduleVersions)
+	moduleImports, err := buildModuleImportsMap(modulesToCheckList, moduleVersions, targetPlatforms)
 	if err != nil {
 		log.Fatalf("Error building module imports map: %s", err)
 	}
@@ -661,6 +1014,11 @@ func main() {
 	sort.Strings(config.Status.UnwantedVendored)
 
 	needUpdate := false
+	var findings []finding
+
+	if checkAllowlistExpiry(configFromFile.Spec.AllowlistExpiry, time.Now(), &findings) {
+		needUpdate = true
+	}
 
 	// Compare unwanted list from unwanted-dependencies.json with current status from `go mod graph`
 	expected, err := json.MarshalIndent(configFromFile.Status, "", "  ")
@@ -672,72 +1030,57 @@ func main() {
 		log.Fatal(err)
 	}
 	if !bytes.Equal(expected, actual) {
-		log.Printf("Expected status of\n%s", string(expected))
-		log.Printf("Got status of\n%s", string(actual))
 		needUpdate = true
-		log.Print("Status diff:\n", cmp.Diff(expected, actual))
+		recordFinding(&findings, "warning", "status-stale", fmt.Sprintf("Expected status of\n%s\nGot status of\n%s\nStatus diff:\n%s", string(expected), string(actual), cmp.Diff(expected, actual)))
 	}
 	for expectedRef, expectedFrom := range configFromFile.Status.UnwantedReferences {
 		actualFrom, ok := config.Status.UnwantedReferences[expectedRef]
 		if !ok {
 			// disappeared entirely
-			log.Printf("Good news! Unwanted dependency %q is no longer referenced. Remove status.unwantedReferences[%q] in %s to ensure it doesn't get reintroduced.", expectedRef, expectedRef, dependenciesJSONPath)
 			needUpdate = true
+			recordFinding(&findings, "info", "reference-removed", fmt.Sprintf("Good news! Unwanted dependency %q is no longer referenced. Remove status.unwantedReferences[%q] in %s to ensure it doesn't get reintroduced.", expectedRef, expectedRef, dependenciesJSONPath))
 			continue
 		}
 		// Check direct references
 		removedDirect, addedDirect := difference(expectedFrom.Direct, actualFrom.Direct)
 		if len(removedDirect) > 0 {
-			log.Printf("Good news! Unwanted module %q dropped the following direct dependants:", expectedRef)
-			for _, reference := range removedDirect {
-				log.Printf("   %s (direct)", reference)
-			}
-			log.Printf("!!! Remove those from status.unwantedReferences[%q].direct in %s to ensure they don't get reintroduced.", expectedRef, dependenciesJSONPath)
 			needUpdate = true
+			recordFinding(&findings, "info", "direct-reference-removed", fmt.Sprintf("Good news! Unwanted module %q dropped the following direct dependants:\n%s\n!!! Remove those from status.unwantedReferences[%q].direct in %s to ensure they don't get reintroduced.", expectedRef, formatReferences(removedDirect, "direct"), expectedRef, dependenciesJSONPath))
 		}
 		if len(addedDirect) > 0 {
-			log.Printf("Unwanted module %q marked in %s is referenced by new direct dependants:", expectedRef, dependenciesJSONPath)
-			for _, reference := range addedDirect {
-				log.Printf("   %s (direct)", reference)
-			}
-			log.Printf("!!! Avoid adding direct dependencies on unwanted modules\n")
 			needUpdate = true
+			recordFinding(&findings, "warning", "direct-reference-added", fmt.Sprintf("Unwanted module %q marked in %s is referenced by new direct dependants:\n%s\n!!! Avoid adding direct dependencies on unwanted modules", expectedRef, dependenciesJSONPath, formatReferences(addedDirect, "direct")))
 		}
 		// Check transitive references (actual importers)
 		removedTransitive, addedTransitive := difference(expectedFrom.Transitive, actualFrom.Transitive)
 		if len(removedTransitive) > 0 {
-			log.Printf("Good news! Unwanted module %q dropped the following transitive dependants:", expectedRef)
-			for _, reference := range removedTransitive {
-				log.Printf("   %s (transitive)", reference)
-			}
-			log.Printf("!!! Remove those from status.unwantedReferences[%q].transitive in %s to ensure they don't get reintroduced.", expectedRef, dependenciesJSONPath)
 			needUpdate = true
+			recordFinding(&findings, "info", "transitive-reference-removed", fmt.Sprintf("Good news! Unwanted module %q dropped the following transitive dependants:\n%s\n!!! Remove those from status.unwantedReferences[%q].transitive in %s to ensure they don't get reintroduced.", expectedRef, formatReferences(removedTransitive, "transitive"), expectedRef, dependenciesJSONPath))
 		}
 		if len(addedTransitive) > 0 {
-			log.Printf("Unwanted module %q marked in %s is referenced by new transitive dependants:", expectedRef, dependenciesJSONPath)
-			for _, reference := range addedTransitive {
-				log.Printf("   %s (transitive)", reference)
-			}
-			log.Printf("!!! Avoid updating referencing modules to versions that reintroduce use of unwanted dependencies\n")
 			needUpdate = true
+			recordFinding(&findings, "warning", "transitive-reference-added", fmt.Sprintf("Unwanted module %q marked in %s is referenced by new transitive dependants:\n%s\n!!! Avoid updating referencing modules to versions that reintroduce use of unwanted dependencies", expectedRef, dependenciesJSONPath, formatReferences(addedTransitive, "transitive")))
 		}
 		// Check goSumOnly references (modules that have the unwanted dep in go.sum but don't actually import it)
 		removedGoSumOnly, addedGoSumOnly := difference(expectedFrom.GoSumOnly, actualFrom.GoSumOnly)
 		if len(removedGoSumOnly) > 0 {
-			log.Printf("Good news! Unwanted module %q dropped the following go.sum-only dependants:", expectedRef)
-			for _, reference := range removedGoSumOnly {
-				log.Printf("   %s (goSumOnly)", reference)
-			}
-			log.Printf("!!! Remove those from status.unwantedReferences[%q].goSumOnly in %s to ensure they don't get reintroduced.", expectedRef, dependenciesJSONPath)
 			needUpdate = true
+			recordFinding(&findings, "info", "gosum-only-reference-removed", fmt.Sprintf("Good news! Unwanted module %q dropped the following go.sum-only dependants:\n%s\n!!! Remove those from status.unwantedReferences[%q].goSumOnly in %s to ensure they don't get reintroduced.", expectedRef, formatReferences(removedGoSumOnly, "goSumOnly"), expectedRef, dependenciesJSONPath))
 		}
 		if len(addedGoSumOnly) > 0 {
-			log.Printf("Unwanted module %q marked in %s has new go.sum-only dependants:", expectedRef, dependenciesJSONPath)
-			for _, reference := range addedGoSumOnly {
-				log.Printf("   %s (goSumOnly - doesn't actually import, just in go.sum)", reference)
-			}
-			log.Printf("!!! These modules don't directly import the unwanted module - fix the 'transitive' modules instead\n")
 			needUpdate = true
+			recordFinding(&findings, "warning", "gosum-only-reference-added", fmt.Sprintf("Unwanted module %q marked in %s has new go.sum-only dependants:\n%s\n!!! These modules don't directly import the unwanted module - fix the 'transitive' modules instead", expectedRef, dependenciesJSONPath, formatReferences(addedGoSumOnly, "goSumOnly - doesn't actually import, just in go.sum")))
+		}
+		// A dependant that's both newly-transitive and no-longer-goSumOnly (or
+		// vice versa) didn't start or stop referencing the unwanted module - it
+		// was promoted to actually importing it, or demoted to just carrying it
+		// in go.sum. Call that out explicitly instead of leaving it implicit
+		// across the two findings above.
+		if promoted := intersectSorted(addedTransitive, removedGoSumOnly); len(promoted) > 0 {
+			recordFinding(&findings, "warning", "gosum-only-promoted-to-transitive", fmt.Sprintf("Unwanted module %q: the following dependants now actually import it, where before it was only in their go.sum:\n%s\n!!! Move these from status.unwantedReferences[%q].goSumOnly to .transitive in %s, and treat them as new transitive dependants", expectedRef, formatReferences(promoted, "promoted"), expectedRef, dependenciesJSONPath))
+		}
+		if demoted := intersectSorted(addedGoSumOnly, removedTransitive); len(demoted) > 0 {
+			recordFinding(&findings, "info", "transitive-demoted-to-gosum-only", fmt.Sprintf("Good news! Unwanted module %q: the following dependants no longer actually import it, and now only carry it in go.sum:\n%s\n!!! Move these from status.unwantedReferences[%q].transitive to .goSumOnly in %s", expectedRef, formatReferences(demoted, "demoted"), expectedRef, dependenciesJSONPath))
 		}
 	}
 	for actualRef, actualFrom := range config.Status.UnwantedReferences {
@@ -745,47 +1088,118 @@ func main() {
 			// expected, already ensured referencers were equal in the first loop
 			continue
 		}
-		log.Printf("Unwanted module %q marked in %s is referenced", actualRef, dependenciesJSONPath)
+		needUpdate = true
+		var refs []string
 		for _, reference := range actualFrom.Direct {
-			log.Printf("   %s (direct)", reference)
+			refs = append(refs, fmt.Sprintf("   %s (direct)", reference))
 		}
 		for _, reference := range actualFrom.Transitive {
-			log.Printf("   %s (transitive - actually imports the unwanted module)", reference)
+			refs = append(refs, fmt.Sprintf("   %s (transitive - actually imports the unwanted module)", reference))
 		}
 		for _, reference := range actualFrom.GoSumOnly {
-			log.Printf("   %s (goSumOnly - doesn't import, just in go.sum)", reference)
+			refs = append(refs, fmt.Sprintf("   %s (goSumOnly - doesn't import, just in go.sum)", reference))
 		}
-		log.Printf("!!! Avoid updating referencing modules to versions that reintroduce use of unwanted dependencies\n")
-		needUpdate = true
+		recordFinding(&findings, "warning", "unwanted-reference-added", fmt.Sprintf("Unwanted module %q marked in %s is referenced\n%s\n!!! Avoid updating referencing modules to versions that reintroduce use of unwanted dependencies", actualRef, dependenciesJSONPath, strings.Join(refs, "\n")))
 	}
 
 	removedVendored, addedVendored := difference(configFromFile.Status.UnwantedVendored, config.Status.UnwantedVendored)
 	if len(removedVendored) > 0 {
-		log.Printf("Good news! Unwanted modules are no longer vendered: %q", removedVendored)
-		log.Printf("!!! Remove those from status.unwantedVendored in %s to ensure they don't get reintroduced.", dependenciesJSONPath)
 		needUpdate = true
+		recordFinding(&findings, "info", "vendored-reference-removed", fmt.Sprintf("Good news! Unwanted modules are no longer vendered: %q\n!!! Remove those from status.unwantedVendored in %s to ensure they don't get reintroduced.", removedVendored, dependenciesJSONPath))
 	}
 	if len(addedVendored) > 0 {
-		log.Printf("Unwanted modules are newly vendored: %q", addedVendored)
-		log.Printf("!!! Avoid updates that increase vendoring of unwanted dependencies\n")
 		needUpdate = true
+		recordFinding(&findings, "warning", "vendored-reference-added", fmt.Sprintf("Unwanted modules are newly vendored: %q\n!!! Avoid updates that increase vendoring of unwanted dependencies", addedVendored))
+	}
+
+	// Check if there are any pinned module violations
+	if len(pinnedModuleViolations) > 0 {
+		var details []string
+		for module, violation := range pinnedModuleViolations {
+			details = append(details, fmt.Sprintf("Module: %s\n  %s", module, strings.Join(violation, "\n  ")))
+		}
+		recordFinding(&findings, "error", "pinned-module-updated", fmt.Sprintf("ERROR: The following pinned modules have been updated:\n%s\nPinned modules must not be updated. Please revert these changes.", strings.Join(details, "\n")))
+	}
+
+	if reportFmt != "text" {
+		if err := writeReport(os.Stdout, reportFmt, findings); err != nil {
+			log.Fatalf("Error encoding %s report: %s", reportFmt, err)
+		}
 	}
 
-	if needUpdate {
+	if needUpdate || len(pinnedModuleViolations) > 0 {
 		os.Exit(1)
 	}
+}
 
-	// Check if there are any pinned module violations
-	if len(pinnedModuleViolations) > 0 {
-		log.Printf("ERROR: The following pinned modules have been updated:")
-		for module, details := range pinnedModuleViolations {
-			log.Printf("Module: %s", module)
-			for _, detail := range details {
-				log.Printf("  %s", detail)
+// formatReferences renders one "   <reference> (<label>)" line per reference,
+// joined with newlines, for inclusion in a finding's message.
+func formatReferences(references []string, label string) string {
+	lines := make([]string, 0, len(references))
+	for _, reference := range references {
+		lines = append(lines, fmt.Sprintf("   %s (%s)", reference, label))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// finding is a single reportable event from the unwanted-references status
+// check, in a form suitable for --format json and --format sarif output.
+type finding struct {
+	Level   string `json:"level"`
+	RuleID  string `json:"ruleId"`
+	Message string `json:"message"`
+}
+
+// recordFinding logs message the same way this tool always has, and also
+// records it as a structured finding for --format json/sarif output.
+func recordFinding(findings *[]finding, level, ruleID, message string) {
+	log.Print(message)
+	*findings = append(*findings, finding{Level: level, RuleID: ruleID, Message: message})
+}
+
+// writeReport encodes findings as either a JSON array (format == "json") or a
+// minimal SARIF 2.1.0 log (format == "sarif") to w.
+func writeReport(w io.Writer, format string, findings []finding) error {
+	switch format {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(findings)
+	case "sarif":
+		results := make([]map[string]any, 0, len(findings))
+		for _, f := range findings {
+			level := f.Level
+			if level == "info" {
+				level = "note"
 			}
+			results = append(results, map[string]any{
+				"ruleId": f.RuleID,
+				"level":  level,
+				"message": map[string]any{
+					"text": f.Message,
+				},
+			})
 		}
-		log.Printf("Pinned modules must not be updated. Please revert these changes.")
-		os.Exit(1)
+		sarifLog := map[string]any{
+			"version": "2.1.0",
+			"$schema": "https://raw.githubusercontent.com/oasis-tcs/sarif-spec/master/Schemata/sarif-schema-2.1.0.json",
+			"runs": []map[string]any{
+				{
+					"tool": map[string]any{
+						"driver": map[string]any{
+							"name":           "dependencyverifier",
+							"informationUri": "https://github.com/kubernetes/kubernetes",
+						},
+					},
+					"results": results,
+				},
+			},
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(sarifLog)
+	default:
+		return fmt.Errorf("unknown report format %q: must be \"text\", \"json\", or \"sarif\"", format)
 	}
 }
 
@@ -811,3 +1225,271 @@ func doVisit(visitor func(m module, via []module), from module, via []module, vi
 	}
 	visited[from] = true
 }
+
+// runWhy implements the `dependencyverifier why <module-path>` subcommand,
+// which prints, for each main module (k8s.io/kubernetes or a staging module)
+// that depends on it, the minimum import chain leading to targetModule. This
+// helps explain why an unwanted dependency is still around, and whether it's
+// worth chasing down from more than one main module.
+func runWhy(args []string) {
+	if len(args) != 1 {
+		log.Fatalf("Usage: %s why <module-path>", os.Args[0])
+	}
+	target := args[0]
+
+	mainModules, moduleGraph, _, effectiveVersions, err := buildModuleGraph()
+	if err != nil {
+		log.Fatalf("Error building module graph: %s", err)
+	}
+
+	found := false
+	for _, mainModule := range mainModules {
+		path := shortestPathTo(target, []module{mainModule}, moduleGraph, effectiveVersions)
+		if path == nil {
+			continue
+		}
+		found = true
+		fmt.Printf("%s:\n", mainModule.name)
+		for i, m := range path {
+			fmt.Printf("%s%s\n", strings.Repeat("  ", i+1), m)
+		}
+	}
+
+	if !found {
+		fmt.Printf("%s is not reachable from any main module\n", target)
+		os.Exit(1)
+	}
+}
+
+// runSuggest implements the `dependencyverifier suggest <unwanted-module>
+// <referencer-module>` subcommand, which probes referencer's published
+// versions for the oldest one newer than its currently-effective version
+// whose go.mod no longer directly requires unwantedModule, as a starting
+// point for eliminating the unwanted dependency.
+func runSuggest(args []string) {
+	if len(args) != 2 {
+		log.Fatalf("Usage: %s suggest <unwanted-module> <referencer-module>", os.Args[0])
+	}
+	unwantedModule, referencer := args[0], args[1]
+
+	_, _, _, effectiveVersions, err := buildModuleGraph()
+	if err != nil {
+		log.Fatalf("Error building module graph: %s", err)
+	}
+
+	current, ok := effectiveVersions[referencer]
+	if !ok {
+		log.Fatalf("%s is not in the effective module graph", referencer)
+	}
+
+	version, err := suggestMinimumVersion(referencer, current.version, unwantedModule)
+	if err != nil {
+		log.Fatalf("Error probing versions of %s: %s", referencer, err)
+	}
+	if version == "" {
+		fmt.Printf("No version of %s newer than %s was found that drops its direct requirement on %s\n", referencer, current.version, unwantedModule)
+		os.Exit(1)
+	}
+	fmt.Printf("go get %s@%s\n", referencer, version)
+}
+
+// suggestMinimumVersion returns the oldest available version of referencer,
+// newer than currentVersion, whose go.mod no longer directly requires
+// unwantedModule, by probing versions reported by `go list -m -versions`
+// oldest-first. It returns "" if no such version is found. This only looks at
+// referencer's own direct requirements, so it won't catch cases where
+// unwantedModule is still pulled in transitively through another dependency.
+func suggestMinimumVersion(referencer, currentVersion, unwantedModule string) (string, error) {
+	output, err := runCommand("go", "list", "-m", "-versions", referencer)
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Fields(output)
+	if len(fields) < 2 {
+		return "", nil
+	}
+	versions := fields[1:] // oldest to newest, per `go list -m -versions`
+
+	for _, v := range versions {
+		if semver.Compare(v, currentVersion) <= 0 {
+			continue
+		}
+
+		downloadOutput, err := runCommand("go", "mod", "download", "-json", referencer+"@"+v)
+		if err != nil {
+			continue
+		}
+		var info struct {
+			GoMod string `json:"GoMod"`
+		}
+		if err := json.Unmarshal([]byte(downloadOutput), &info); err != nil || info.GoMod == "" {
+			continue
+		}
+		goModContent, err := os.ReadFile(info.GoMod)
+		if err != nil {
+			continue
+		}
+		direct, err := directRequiresFromGoMod(info.GoMod, goModContent)
+		if err != nil {
+			continue
+		}
+		if !direct[unwantedModule] {
+			return v, nil
+		}
+	}
+
+	return "", nil
+}
+
+// runSBOM implements the `dependencyverifier sbom <spdx|cyclonedx>`
+// subcommand, which emits a software bill of materials covering every module
+// in the effective (MVS-selected) dependency graph. It only builds the module
+// graph and prints the SBOM to stdout; it does not run the unwanted-references
+// status check.
+func runSBOM(args []string) {
+	if len(args) != 1 {
+		log.Fatalf("Usage: %s sbom <spdx|cyclonedx>", os.Args[0])
+	}
+	format := args[0]
+
+	_, _, _, effectiveVersions, err := buildModuleGraph()
+	if err != nil {
+		log.Fatalf("Error building module graph: %s", err)
+	}
+
+	names := make([]string, 0, len(effectiveVersions))
+	for name := range effectiveVersions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var out []byte
+	switch format {
+	case "spdx":
+		out, err = json.MarshalIndent(spdxDocument(names, effectiveVersions), "", "  ")
+	case "cyclonedx":
+		out, err = json.MarshalIndent(cyclonedxBOM(names, effectiveVersions), "", "  ")
+	default:
+		log.Fatalf("Unknown SBOM format %q: must be \"spdx\" or \"cyclonedx\"", format)
+	}
+	if err != nil {
+		log.Fatalf("Error encoding SBOM: %s", err)
+	}
+
+	fmt.Println(string(out))
+}
+
+// spdxPackage is a single entry in an SPDX 2.3 document's "packages" array.
+type spdxPackage struct {
+	SPDXID           string `json:"SPDXID"`
+	Name             string `json:"name"`
+	VersionInfo      string `json:"versionInfo,omitempty"`
+	DownloadLocation string `json:"downloadLocation"`
+}
+
+// spdxDocument builds a minimal SPDX 2.3 JSON document covering the named
+// modules, with versions taken from effectiveVersions.
+func spdxDocument(names []string, effectiveVersions map[string]module) map[string]any {
+	packages := make([]spdxPackage, 0, len(names))
+	for _, name := range names {
+		packages = append(packages, spdxPackage{
+			SPDXID:           "SPDXRef-Package-" + strings.NewReplacer("/", "-", ".", "-").Replace(name),
+			Name:             name,
+			VersionInfo:      effectiveVersions[name].version,
+			DownloadLocation: "https://" + name,
+		})
+	}
+
+	return map[string]any{
+		"spdxVersion":       "SPDX-2.3",
+		"dataLicense":       "CC0-1.0",
+		"SPDXID":            "SPDXRef-DOCUMENT",
+		"name":              "k8s.io/kubernetes-dependencies",
+		"documentNamespace": "https://k8s.io/kubernetes/dependencies-" + time.Now().UTC().Format(time.RFC3339),
+		"creationInfo": map[string]any{
+			"created":  time.Now().UTC().Format(time.RFC3339),
+			"creators": []string{"Tool: dependencyverifier"},
+		},
+		"packages": packages,
+	}
+}
+
+// cyclonedxComponent is a single entry in a CycloneDX BOM's "components" array.
+type cyclonedxComponent struct {
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+	PURL    string `json:"purl,omitempty"`
+}
+
+// cyclonedxBOM builds a minimal CycloneDX 1.5 JSON BOM covering the named
+// modules, with versions taken from effectiveVersions.
+func cyclonedxBOM(names []string, effectiveVersions map[string]module) map[string]any {
+	components := make([]cyclonedxComponent, 0, len(names))
+	for _, name := range names {
+		version := effectiveVersions[name].version
+		purl := "pkg:golang/" + name
+		if version != "" {
+			purl += "@" + version
+		}
+		components = append(components, cyclonedxComponent{
+			Type:    "library",
+			Name:    name,
+			Version: version,
+			PURL:    purl,
+		})
+	}
+
+	return map[string]any{
+		"bomFormat":   "CycloneDX",
+		"specVersion": "1.5",
+		"version":     1,
+		"metadata": map[string]any{
+			"timestamp": time.Now().UTC().Format(time.RFC3339),
+		},
+		"components": components,
+	}
+}
+
+// shortestPathTo returns the shortest chain of modules, starting at one of
+// mainModules, that reaches a module named target, applying effectiveVersions
+// overrides the same way the graph traversal in doVisit does. It returns nil
+// if target isn't reachable from any main module.
+func shortestPathTo(target string, mainModules []module, moduleGraph map[module][]module, effectiveVersions map[string]module) []module {
+	type queueItem struct {
+		m    module
+		path []module
+	}
+
+	visited := map[module]bool{}
+	var queue []queueItem
+	for _, m := range mainModules {
+		if !visited[m] {
+			visited[m] = true
+			queue = append(queue, queueItem{m: m, path: []module{m}})
+		}
+	}
+
+	for len(queue) > 0 {
+		item := queue[0]
+		queue = queue[1:]
+
+		if item.m.name == target {
+			return item.path
+		}
+
+		for _, to := range moduleGraph[item.m] {
+			if override, ok := effectiveVersions[to.name]; ok {
+				to = override
+			}
+			if visited[to] {
+				continue
+			}
+			visited[to] = true
+			path := append(append([]module{}, item.path...), to)
+			queue = append(queue, queueItem{m: to, path: path})
+		}
+	}
+
+	return nil
+}
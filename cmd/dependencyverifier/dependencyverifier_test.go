@@ -0,0 +1,199 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestConvertToMap(t *testing.T) {
+	graph := "k8s.io/kubernetes a@v1.0.0\n" +
+		"a@v1.0.0 b@v2.0.0\n" +
+		"a@v1.0.0 c@v3.0.0\n"
+
+	mainModules, modMap := convertToMap(graph)
+
+	wantMain := []module{{name: "k8s.io/kubernetes"}}
+	if !reflect.DeepEqual(mainModules, wantMain) {
+		t.Errorf("mainModules = %v, want %v", mainModules, wantMain)
+	}
+
+	a := module{name: "a", version: "v1.0.0"}
+	want := []module{
+		{name: "b", version: "v2.0.0"},
+		{name: "c", version: "v3.0.0"},
+	}
+	if !reflect.DeepEqual(modMap[a], want) {
+		t.Errorf("modMap[a] = %v, want %v", modMap[a], want)
+	}
+}
+
+func TestMergeGraphEdgesRecoversPrunedRequirements(t *testing.T) {
+	main := module{name: "k8s.io/kubernetes"}
+	unwanted := module{name: "unwanted", version: "v1.0.0"}
+	other := module{name: "other", version: "v2.0.0"}
+
+	// The pruned graph has no edge at all to the unwanted module - go 1.17+
+	// lazy loading hid it because nothing in the build list imports it.
+	pruned := map[module][]module{
+		main: {other},
+	}
+	// The unpruned graph for the same main module reveals the hidden
+	// requirement.
+	full := map[module][]module{
+		main: {other, unwanted},
+	}
+
+	mergeGraphEdges(pruned, full)
+
+	want := []module{other, unwanted}
+	if !reflect.DeepEqual(pruned[main], want) {
+		t.Errorf("pruned[main] after merge = %v, want %v", pruned[main], want)
+	}
+}
+
+func TestMergeGraphEdgesSkipsExistingEdges(t *testing.T) {
+	main := module{name: "k8s.io/kubernetes"}
+	other := module{name: "other", version: "v2.0.0"}
+
+	graph := map[module][]module{main: {other}}
+	additional := map[module][]module{main: {other}}
+
+	mergeGraphEdges(graph, additional)
+
+	if want := []module{other}; !reflect.DeepEqual(graph[main], want) {
+		t.Errorf("graph[main] = %v, want %v (no duplicate edge)", graph[main], want)
+	}
+}
+
+func TestShortestPathTo(t *testing.T) {
+	main1 := module{name: "k8s.io/kubernetes"}
+	main2 := module{name: "k8s.io/component-base"}
+	mid := module{name: "mid", version: "v1.0.0"}
+	target := module{name: "target", version: "v1.0.0"}
+	targetOld := module{name: "target", version: "v0.9.0"}
+
+	moduleGraph := map[module][]module{
+		main1: {mid},
+		mid:   {targetOld}, // overridden to target by effectiveVersions
+		main2: {targetOld}, // a direct, longer-looking but actually shorter path
+	}
+	effectiveVersions := map[string]module{
+		"target": target,
+		"mid":    mid,
+	}
+
+	path := shortestPathTo("target", []module{main1, main2}, moduleGraph, effectiveVersions)
+
+	want := []module{main2, target}
+	if !reflect.DeepEqual(path, want) {
+		t.Errorf("shortestPathTo() = %v, want %v (shortest path via main2, not the 3-hop path via main1)", path, want)
+	}
+}
+
+func TestShortestPathToUnreachable(t *testing.T) {
+	main := module{name: "k8s.io/kubernetes"}
+	moduleGraph := map[module][]module{
+		main: {{name: "other", version: "v1.0.0"}},
+	}
+
+	if path := shortestPathTo("nowhere", []module{main}, moduleGraph, nil); path != nil {
+		t.Errorf("shortestPathTo() = %v, want nil for an unreachable target", path)
+	}
+}
+
+func TestDirectRequiresFromGoMod(t *testing.T) {
+	goMod := []byte(`module example.com/foo
+
+go 1.21
+
+require (
+	example.com/direct v1.0.0
+	example.com/indirect v1.0.0 // indirect
+)
+`)
+
+	direct, err := directRequiresFromGoMod("go.mod", goMod)
+	if err != nil {
+		t.Fatalf("directRequiresFromGoMod() error = %v", err)
+	}
+	if !direct["example.com/direct"] {
+		t.Error("expected example.com/direct to be reported as a direct requirement")
+	}
+	if direct["example.com/indirect"] {
+		t.Error("expected example.com/indirect to not be reported as a direct requirement")
+	}
+}
+
+func TestFindModuleForPackage(t *testing.T) {
+	moduleVersions := map[string]string{
+		"k8s.io/apimachinery": "v1.0.0",
+		"k8s.io/api":          "v1.0.0",
+	}
+
+	tests := []struct {
+		pkg  string
+		want string
+	}{
+		{"k8s.io/apimachinery/pkg/util/diff", "k8s.io/apimachinery"},
+		{"k8s.io/api", "k8s.io/api"},
+		{"k8s.io/unknown/pkg", ""},
+	}
+	for _, tc := range tests {
+		if got := findModuleForPackage(tc.pkg, moduleVersions); got != tc.want {
+			t.Errorf("findModuleForPackage(%q) = %q, want %q", tc.pkg, got, tc.want)
+		}
+	}
+}
+
+func TestSpdxDocumentIncludesAllModules(t *testing.T) {
+	effectiveVersions := map[string]module{
+		"k8s.io/api": {name: "k8s.io/api", version: "v1.2.3"},
+	}
+	doc := spdxDocument([]string{"k8s.io/api"}, effectiveVersions)
+
+	packages, ok := doc["packages"].([]spdxPackage)
+	if !ok || len(packages) != 1 {
+		t.Fatalf("packages = %#v, want a single spdxPackage", doc["packages"])
+	}
+	if packages[0].Name != "k8s.io/api" || packages[0].VersionInfo != "v1.2.3" {
+		t.Errorf("packages[0] = %+v, want Name=k8s.io/api VersionInfo=v1.2.3", packages[0])
+	}
+	if doc["spdxVersion"] != "SPDX-2.3" {
+		t.Errorf("spdxVersion = %v, want SPDX-2.3", doc["spdxVersion"])
+	}
+}
+
+func TestCyclonedxBOMIncludesAllModules(t *testing.T) {
+	effectiveVersions := map[string]module{
+		"k8s.io/api": {name: "k8s.io/api", version: "v1.2.3"},
+	}
+	bom := cyclonedxBOM([]string{"k8s.io/api"}, effectiveVersions)
+
+	components, ok := bom["components"].([]cyclonedxComponent)
+	if !ok || len(components) != 1 {
+		t.Fatalf("components = %#v, want a single cyclonedxComponent", bom["components"])
+	}
+	want := cyclonedxComponent{Type: "library", Name: "k8s.io/api", Version: "v1.2.3", PURL: "pkg:golang/k8s.io/api@v1.2.3"}
+	if components[0] != want {
+		t.Errorf("components[0] = %+v, want %+v", components[0], want)
+	}
+	if bom["bomFormat"] != "CycloneDX" {
+		t.Errorf("bomFormat = %v, want CycloneDX", bom["bomFormat"])
+	}
+}
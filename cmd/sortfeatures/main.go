@@ -17,6 +17,7 @@ limitations under the License.
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"go/ast"
 	"go/parser"
@@ -31,16 +32,55 @@ import (
 var (
 	// Command line flags
 	forceUpdate bool
+	checkOnly   bool
+	outputFmt   string
 	files       []string
 )
 
+// blockReport describes a single out-of-order var/const block or
+// feature-gate map literal found while processing a file, and is the unit
+// emitted in --format json/github reports for CI consumption.
+type blockReport struct {
+	Path     string   `json:"path"`
+	Kind     string   `json:"kind"` // "var", "const", or "map"
+	Line     int      `json:"line"`
+	Original []string `json:"original"`
+	Sorted   []string `json:"sorted"`
+}
+
+// firstInversion returns the first adjacent pair (x, y) in original such
+// that x comes after y in sorted - i.e. y should sort before x, but doesn't -
+// for use in a human-readable annotation message. It returns ok=false if
+// original is already in sorted order.
+func firstInversion(original, sorted []string) (x, y string, ok bool) {
+	pos := make(map[string]int, len(sorted))
+	for i, s := range sorted {
+		pos[s] = i
+	}
+	for i := 0; i < len(original)-1; i++ {
+		if pos[original[i]] > pos[original[i+1]] {
+			return original[i], original[i+1], true
+		}
+	}
+	return "", "", false
+}
+
 func main() {
 	rootCmd := &cobra.Command{
-		Use:   "sortfeatures",
-		Short: "Sort feature declarations in Kubernetes feature files",
+		Use:           "sortfeatures",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		Short:         "Sort feature declarations in Kubernetes feature files",
 		Long: `Sort feature declarations in Kubernetes feature files.
-This tool parses specified files, finds var/const blocks containing feature declarations,
-sorts them alphabetically (case-sensitive), and updates the files if the order has changed.`,
+This tool parses specified files, finds var/const blocks and feature-gate map
+literals containing feature declarations, sorts them alphabetically
+(case-sensitive; map entries are first grouped by lifecycle stage - Alpha,
+Beta, GA, Deprecated, then entries with no recognized stage), and updates
+the files if the order has changed.
+
+With --check, no files are modified; the tool only reports whether any files
+are out of order, via a non-zero exit code and (with --format json or
+--format github) a machine-readable report suitable for CI.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// If no files are specified via the --files flag, use positional args
 			files = append(files, args...)
@@ -48,50 +88,94 @@ sorts them alphabetically (case-sensitive), and updates the files if the order h
 			if len(files) == 0 {
 				return fmt.Errorf("no files specified, use --files flag or provide file paths as arguments")
 			}
+			if outputFmt != "text" && outputFmt != "json" && outputFmt != "github" {
+				return fmt.Errorf("invalid --format %q: must be \"text\", \"json\", or \"github\"", outputFmt)
+			}
 
+			var blocks []blockReport
+			anyUnsorted := false
 			for _, filePath := range files {
-				if err := processFile(filePath); err != nil {
+				changed, fileBlocks, err := processFile(filePath)
+				if err != nil {
 					return err
 				}
+				if changed {
+					anyUnsorted = true
+				}
+				blocks = append(blocks, fileBlocks...)
+			}
+
+			switch outputFmt {
+			case "json":
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(blocks); err != nil {
+					return fmt.Errorf("failed to encode JSON report: %w", err)
+				}
+			case "github":
+				for _, b := range blocks {
+					x, y, ok := firstInversion(b.Original, b.Sorted)
+					if !ok {
+						continue
+					}
+					fmt.Printf("::error file=%s,line=%d::feature %s should sort before %s\n", b.Path, b.Line, y, x)
+				}
+			}
+
+			if checkOnly && anyUnsorted {
+				return errCheckFailed
 			}
 			return nil
 		},
 	}
 
 	rootCmd.Flags().BoolVarP(&forceUpdate, "force", "f", false, "Force update even if the file is already sorted")
+	rootCmd.Flags().BoolVarP(&checkOnly, "check", "c", false, "Only check whether files are sorted, without modifying them; exits non-zero if not")
+	rootCmd.Flags().StringVarP(&outputFmt, "format", "", "text", "Report format: \"text\", \"json\", or \"github\" (for CI consumption; github emits ::error file=...,line=...:: annotations)")
 	rootCmd.Flags().StringSliceVarP(&files, "files", "", nil, "One or more file paths to process")
 
 	if err := rootCmd.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		if err != errCheckFailed {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
 		os.Exit(1)
 	}
 }
 
-// processFile processes a single file
-func processFile(filePath string) error {
+// errCheckFailed is returned by RunE when --check finds unsorted files, so
+// main can exit(1) without printing a redundant "Error: ..." line; the
+// detailed report was already emitted to stdout.
+var errCheckFailed = fmt.Errorf("one or more files are not sorted")
+
+// processFile sorts the feature declarations in filePath. It returns whether
+// the file's declarations were (or, in --check mode, would be) reordered,
+// and a blockReport for each out-of-order var/const block or feature-gate
+// map literal found. In --check mode the file on disk is never modified.
+func processFile(filePath string) (bool, []blockReport, error) {
 	// Check if file exists
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return fmt.Errorf("file does not exist: %s", filePath)
+		return false, nil, fmt.Errorf("file does not exist: %s", filePath)
 	}
 
-	fmt.Printf("Processing %s\n", filePath)
+	fmt.Fprintf(os.Stderr, "Processing %s\n", filePath)
 
 	// Read the file content
 	content, err := os.ReadFile(filePath)
 	if err != nil {
-		return fmt.Errorf("failed to read file: %w", err)
+		return false, nil, fmt.Errorf("failed to read file: %w", err)
 	}
 
 	// Parse the file
 	fset := token.NewFileSet()
 	file, err := parser.ParseFile(fset, filePath, content, parser.ParseComments)
 	if err != nil {
-		return fmt.Errorf("failed to parse file: %w", err)
+		return false, nil, fmt.Errorf("failed to parse file: %w", err)
 	}
 
 	// Track if any changes were made
 	fileChanged := false
 	newContent := string(content)
+	var blocks []blockReport
 
 	// Process each declaration in the file
 	for _, decl := range file.Decls {
@@ -119,27 +203,100 @@ func processFile(filePath string) error {
 		// Check if the order has changed
 		orderChanged := hasOrderChanged(features, sortedFeatures)
 
+		if orderChanged {
+			blocks = append(blocks, blockReport{
+				Path:     filePath,
+				Kind:     tokenToString(genDecl.Tok),
+				Line:     fset.Position(genDecl.Pos()).Line,
+				Original: featureNames(features),
+				Sorted:   featureNames(sortedFeatures),
+			})
+		}
+
 		// Update the file if the order has changed or force update is enabled
 		if orderChanged || forceUpdate {
 			// Create a new file with sorted features
 			newContent = updateFile(newContent, genDecl, sortedFeatures, fset)
 
 			fileChanged = true
-			fmt.Printf("  Reordered %d features in %s block\n", len(sortedFeatures), tokenToString(genDecl.Tok))
+			fmt.Fprintf(os.Stderr, "  Reordered %d features in %s block\n", len(sortedFeatures), tokenToString(genDecl.Tok))
 		}
 	}
 
-	// Write the updated file if changes were made
+	// Re-parse, since the var/const pass above may have shifted offsets that
+	// the map-literal pass below relies on.
+	fset = token.NewFileSet()
+	file, err = parser.ParseFile(fset, filePath, newContent, parser.ParseComments)
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to re-parse file after sorting declarations: %w", err)
+	}
+
+	// Process feature-gate map literals (e.g. map[featuregate.Feature]FeatureSpec{...}).
+	ast.Inspect(file, func(n ast.Node) bool {
+		lit, ok := n.(*ast.CompositeLit)
+		if !ok || !isFeatureMapType(lit.Type) {
+			return true
+		}
+
+		entries := extractMapEntries(lit, file.Comments, fset, newContent)
+		if len(entries) <= 1 {
+			return true
+		}
+
+		sortedEntries := sortMapEntries(entries)
+		orderChanged := hasMapOrderChanged(entries, sortedEntries)
+		if orderChanged {
+			blocks = append(blocks, blockReport{
+				Path:     filePath,
+				Kind:     "map",
+				Line:     fset.Position(lit.Lbrace).Line,
+				Original: mapEntryKeys(entries),
+				Sorted:   mapEntryKeys(sortedEntries),
+			})
+		}
+		if !orderChanged && !forceUpdate {
+			return true
+		}
+
+		newContent = updateMapLiteral(newContent, lit, sortedEntries, fset)
+		fileChanged = true
+		fmt.Fprintf(os.Stderr, "  Reordered %d feature-gate map entries\n", len(sortedEntries))
+		return true
+	})
+
+	// Write the updated file if changes were made, unless we're only checking.
 	if fileChanged {
-		if err := os.WriteFile(filePath, []byte(newContent), 0644); err != nil {
-			return fmt.Errorf("failed to write file: %w", err)
+		if checkOnly {
+			fmt.Fprintf(os.Stderr, "%s is not sorted\n", filePath)
+		} else {
+			if err := os.WriteFile(filePath, []byte(newContent), 0644); err != nil {
+				return false, nil, fmt.Errorf("failed to write file: %w", err)
+			}
+			fmt.Fprintf(os.Stderr, "Updated %s\n", filePath)
 		}
-		fmt.Printf("Updated %s\n", filePath)
 	} else {
-		fmt.Printf("No changes needed for %s\n", filePath)
+		fmt.Fprintf(os.Stderr, "No changes needed for %s\n", filePath)
 	}
 
-	return nil
+	return fileChanged, blocks, nil
+}
+
+// featureNames returns the Name of each feature, in order.
+func featureNames(features []Feature) []string {
+	names := make([]string, len(features))
+	for i, f := range features {
+		names[i] = f.Name
+	}
+	return names
+}
+
+// mapEntryKeys returns the Key of each map entry, in order.
+func mapEntryKeys(entries []mapEntry) []string {
+	keys := make([]string, len(entries))
+	for i, e := range entries {
+		keys[i] = e.Key
+	}
+	return keys
 }
 
 // tokenToString converts a token to its string representation
@@ -294,3 +451,223 @@ func updateFile(content string, decl *ast.GenDecl, sortedFeatures []Feature, fse
 
 	return buf.String()
 }
+
+// mapEntry represents a single key/value pair of a feature-gate map literal.
+type mapEntry struct {
+	Key      string       // the literal source text of the map key (e.g. `"FeatureA"` or `FeatureA`)
+	Stage    featureStage // lifecycle stage extracted from the entry's FeatureSpec value, if any
+	Comments []string     // comments associated with this entry
+	Line     string       // the entire "key: value," source text of the entry
+}
+
+// featureStage classifies a feature-gate map entry by its lifecycle stage, as
+// found in a featuregate.PreRelease value within its FeatureSpec. Entries are
+// grouped by stage, in this order, before being sorted alphabetically within
+// each group.
+type featureStage int
+
+const (
+	stageAlpha featureStage = iota
+	stageBeta
+	stageGA
+	stageDeprecated
+	stageUnknown
+)
+
+// String returns the section header used to introduce entries of stage s, or
+// the empty string for stageUnknown, which gets no header.
+func (s featureStage) String() string {
+	switch s {
+	case stageAlpha:
+		return "Alpha features"
+	case stageBeta:
+		return "Beta features"
+	case stageGA:
+		return "GA features"
+	case stageDeprecated:
+		return "Deprecated features"
+	default:
+		return ""
+	}
+}
+
+// extractStage looks for a featuregate.<Stage> reference in entryText, the
+// source text of a map entry, and returns the corresponding stage, or
+// stageUnknown if none is found.
+func extractStage(entryText string) featureStage {
+	switch {
+	case strings.Contains(entryText, "featuregate.Deprecated"):
+		return stageDeprecated
+	case strings.Contains(entryText, "featuregate.GA"):
+		return stageGA
+	case strings.Contains(entryText, "featuregate.Beta"):
+		return stageBeta
+	case strings.Contains(entryText, "featuregate.Alpha"):
+		return stageAlpha
+	default:
+		return stageUnknown
+	}
+}
+
+// isFeatureMapType reports whether t is a map type whose key type looks like
+// a feature-gate identifier, e.g. map[featuregate.Feature]FeatureSpec or
+// map[Feature]bool. This is a heuristic based on the key type's name, since
+// sortfeatures has no type-checking information available.
+func isFeatureMapType(t ast.Expr) bool {
+	mapType, ok := t.(*ast.MapType)
+	if !ok {
+		return false
+	}
+
+	var keyName string
+	switch key := mapType.Key.(type) {
+	case *ast.Ident:
+		keyName = key.Name
+	case *ast.SelectorExpr:
+		keyName = key.Sel.Name
+	default:
+		return false
+	}
+
+	return strings.Contains(keyName, "Feature")
+}
+
+// extractMapEntries extracts the key/value entries of a feature-gate map
+// literal, in source order, together with any comments attached to each entry.
+func extractMapEntries(lit *ast.CompositeLit, comments []*ast.CommentGroup, fset *token.FileSet, content string) []mapEntry {
+	var entries []mapEntry
+
+	for _, elt := range lit.Elts {
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			continue
+		}
+
+		keyStart := fset.Position(kv.Key.Pos())
+		keyEnd := fset.Position(kv.Key.End())
+		key := content[keyStart.Offset:keyEnd.Offset]
+
+		// KeyValueExpr has no Doc field the way ValueSpec does, so find the
+		// comment group immediately above this entry by checking that only
+		// whitespace separates the comment from the entry in the source.
+		var entryComments []string
+		for _, cg := range comments {
+			cgEnd := fset.Position(cg.End()).Offset
+			kvStart := fset.Position(kv.Pos()).Offset
+			if cgEnd <= kvStart && strings.TrimSpace(content[cgEnd:kvStart]) == "" {
+				for _, comment := range cg.List {
+					entryComments = append(entryComments, comment.Text)
+				}
+			}
+		}
+
+		// Capture the entire line of the entry, same approach as extractFeatures.
+		// The entry is followed by a trailing comma that isn't part of the
+		// KeyValueExpr node itself; include it so the line is ready to emit as-is.
+		start := fset.Position(kv.Pos())
+		end := fset.Position(kv.End())
+		lineStart := start.Offset
+		for lineStart > 0 && content[lineStart-1] != '\n' && content[lineStart-1] != '\t' {
+			lineStart--
+		}
+		lineEnd := end.Offset
+		if lineEnd < len(content) && content[lineEnd] == ',' {
+			lineEnd++
+		}
+		line := content[lineStart:lineEnd]
+
+		entries = append(entries, mapEntry{
+			Key:      key,
+			Stage:    extractStage(line),
+			Comments: entryComments,
+			Line:     line,
+		})
+	}
+
+	return entries
+}
+
+// sortMapEntries groups map entries by lifecycle stage (Alpha, Beta, GA,
+// Deprecated, then entries with no recognized stage), and sorts entries
+// alphabetically by key within each group.
+func sortMapEntries(entries []mapEntry) []mapEntry {
+	sorted := make([]mapEntry, len(entries))
+	copy(sorted, entries)
+
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Stage != sorted[j].Stage {
+			return sorted[i].Stage < sorted[j].Stage
+		}
+		return sorted[i].Key < sorted[j].Key
+	})
+
+	return sorted
+}
+
+// hasMapOrderChanged checks if the order of map entries has changed.
+func hasMapOrderChanged(original, sorted []mapEntry) bool {
+	if len(original) != len(sorted) {
+		return true
+	}
+
+	for i := range original {
+		if original[i].Key != sorted[i].Key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// updateMapLiteral creates a new file content with sorted map entries.
+func updateMapLiteral(content string, lit *ast.CompositeLit, sortedEntries []mapEntry, fset *token.FileSet) string {
+	var buf strings.Builder
+
+	// Find the start of the line containing the opening brace.
+	litStart := fset.Position(lit.Lbrace)
+	litEnd := fset.Position(lit.Rbrace)
+
+	lineStart := litStart.Offset
+	for lineStart > 0 && content[lineStart-1] != '\n' {
+		lineStart--
+	}
+
+	buf.WriteString(content[:lineStart])
+	buf.WriteString(content[lineStart : litStart.Offset+1]) // through the "{"
+
+	prevStage := featureStage(-1)
+	for i, entry := range sortedEntries {
+		if i > 0 && entry.Stage != prevStage {
+			buf.WriteString("\n")
+		}
+		buf.WriteString("\n")
+
+		if entry.Stage != prevStage && entry.Stage != stageUnknown {
+			buf.WriteString("\t// ")
+			buf.WriteString(entry.Stage.String())
+			buf.WriteString("\n")
+		}
+		prevStage = entry.Stage
+
+		for _, comment := range entry.Comments {
+			buf.WriteString("\t")
+			buf.WriteString(comment)
+			buf.WriteString("\n")
+		}
+
+		buf.WriteString("\t")
+		buf.WriteString(strings.TrimSuffix(entry.Line, ","))
+		buf.WriteString(",")
+	}
+
+	// Preserve the original indentation of the line holding the closing brace.
+	closeLineStart := litEnd.Offset
+	for closeLineStart > 0 && content[closeLineStart-1] != '\n' {
+		closeLineStart--
+	}
+	buf.WriteString("\n")
+	buf.WriteString(content[closeLineStart:litEnd.Offset])
+	buf.WriteString(content[litEnd.Offset:])
+
+	return buf.String()
+}
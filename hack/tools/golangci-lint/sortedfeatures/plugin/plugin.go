@@ -33,6 +33,14 @@ type settings struct {
 	Debug bool `json:"debug"`
 	// Files specifies which files to check
 	Files []string `json:"files"`
+	// Groups lists the exact section names a heading comment may introduce
+	// (e.g. "Alpha", "Beta", "GA"), sorting feature gates within each
+	// section independently. See sortedfeatures.Config.Groups.
+	Groups []string `json:"groups"`
+	// IgnoreRegexps lists patterns matched against a feature's full name;
+	// matching features keep their original position instead of being
+	// reordered. See sortedfeatures.Config.IgnoreRegexps.
+	IgnoreRegexps []string `json:"ignoreRegexps"`
 }
 
 // New is the entry point for golangci-lint plugin system
@@ -63,6 +71,13 @@ func New(pluginSettings interface{}) ([]*analysis.Analyzer, error) {
 			config.Files = append(config.Files, s.Files...)
 		}
 
+		if len(s.Groups) > 0 {
+			config.Groups = append(config.Groups, s.Groups...)
+		}
+		if len(s.IgnoreRegexps) > 0 {
+			config.IgnoreRegexps = append(config.IgnoreRegexps, s.IgnoreRegexps...)
+		}
+
 		if config.Debug {
 			fmt.Printf("sortedfeatures settings: %+v\n", s)
 			fmt.Printf("final config: %+v\n", config)
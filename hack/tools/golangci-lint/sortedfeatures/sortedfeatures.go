@@ -21,7 +21,9 @@ import (
 	"fmt"
 	"go/ast"
 	"go/token"
+	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
 
@@ -30,6 +32,12 @@ import (
 	"golang.org/x/tools/go/analysis"
 )
 
+// defaultGroupMarkerPattern matches a heading comment like "// Alpha
+// features:" that introduces a new section within a var/const block, e.g.
+// the Alpha/Beta/GA/deprecated/per-SIG groupings real kube_features.go
+// files use.
+const defaultGroupMarkerPattern = `^//\s*[A-Z][A-Za-z0-9/ -]+:$`
+
 // List of default files to check for feature gate sorting
 var defaultTargetFiles = []string{
 	"pkg/features/kube_features.go",
@@ -47,6 +55,50 @@ type Config struct {
 	Files []string
 	// Debug enables debug logging
 	Debug bool
+	// GroupMarkerPrefix is a regexp (despite the name, not a literal prefix)
+	// matching heading comments that introduce a new section within a
+	// var/const block. Feature gates are sorted alphabetically within each
+	// section independently; section order and the marker comments
+	// themselves are left untouched. Defaults to defaultGroupMarkerPattern
+	// when empty. Ignored if Groups is non-empty.
+	GroupMarkerPrefix string
+	// Groups lists the exact section names a heading comment is allowed to
+	// introduce, e.g. []string{"Alpha", "Beta", "GA"} for a heading comment
+	// like "// Alpha features:". Unlike GroupMarkerPrefix's generic pattern,
+	// a heading whose name isn't in this list is treated as an ordinary
+	// comment, not a section marker - catching a typo'd or unexpected
+	// heading instead of silently starting a new (unsorted-against)
+	// section for it. Empty uses GroupMarkerPrefix instead.
+	Groups []string
+	// IgnoreNames lists feature names excluded from the ordering check
+	// entirely, e.g. a migration gate intentionally left out of place.
+	IgnoreNames []string
+	// IgnorePrefixes lists feature name prefixes excluded from the ordering
+	// check, same as IgnoreNames but matched as a prefix.
+	IgnorePrefixes []string
+	// IgnoreRegexps lists patterns matched against the full feature name,
+	// same as IgnoreNames/IgnorePrefixes but for names that don't share a
+	// literal prefix, e.g. a family of deprecated aliases matched by
+	// "Deprecated.*Gate".
+	IgnoreRegexps []string
+}
+
+// groupMarkerPatternFor returns the regexp collectGroupMarkers should use:
+// one matching only the exact section names in groups, if non-empty,
+// otherwise markerPrefix, falling back to defaultGroupMarkerPattern if that
+// too is empty.
+func groupMarkerPatternFor(groups []string, markerPrefix string) string {
+	if len(groups) > 0 {
+		names := make([]string, len(groups))
+		for i, g := range groups {
+			names[i] = regexp.QuoteMeta(g)
+		}
+		return `^//\s*(?:` + strings.Join(names, "|") + `)\b.*:$`
+	}
+	if markerPrefix != "" {
+		return markerPrefix
+	}
+	return defaultGroupMarkerPattern
 }
 
 // NewAnalyzer returns a new sortedfeatures analyzer.
@@ -56,16 +108,62 @@ func NewAnalyzer() *analysis.Analyzer {
 
 // NewAnalyzerWithConfig returns a new sortedfeatures analyzer with the given configuration.
 func NewAnalyzerWithConfig(config Config) *analysis.Analyzer {
+	groupMarkerRe, groupErr := regexp.Compile(groupMarkerPatternFor(config.Groups, config.GroupMarkerPrefix))
+	ignore, ignoreErr := ignoreMatcher(config)
+
 	return &analysis.Analyzer{
 		Name: "sortedfeatures",
 		Doc:  "Checks if feature gates are sorted alphabetically in const and var blocks",
 		Run: func(pass *analysis.Pass) (interface{}, error) {
-			return run(pass, config)
+			if groupErr != nil {
+				return nil, fmt.Errorf("sortedfeatures: invalid GroupMarkerPrefix/Groups: %w", groupErr)
+			}
+			if ignoreErr != nil {
+				return nil, fmt.Errorf("sortedfeatures: invalid IgnoreRegexps: %w", ignoreErr)
+			}
+			return run(pass, config, groupMarkerRe, ignore)
 		},
 	}
 }
 
-func run(pass *analysis.Pass, config Config) (interface{}, error) {
+// ignoreMatcher reports whether a feature name is pinned in place by
+// Config.IgnoreNames, Config.IgnorePrefixes, or Config.IgnoreRegexps, and so
+// should be skipped by the ordering check entirely.
+func ignoreMatcher(config Config) (func(name string) bool, error) {
+	names := make(map[string]bool, len(config.IgnoreNames))
+	for _, n := range config.IgnoreNames {
+		names[n] = true
+	}
+	prefixes := config.IgnorePrefixes
+
+	regexps := make([]*regexp.Regexp, len(config.IgnoreRegexps))
+	for i, pattern := range config.IgnoreRegexps {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid IgnoreRegexps pattern %q: %w", pattern, err)
+		}
+		regexps[i] = re
+	}
+
+	return func(name string) bool {
+		if names[name] {
+			return true
+		}
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		}
+		for _, re := range regexps {
+			if re.MatchString(name) {
+				return true
+			}
+		}
+		return false
+	}, nil
+}
+
+func run(pass *analysis.Pass, config Config, groupMarkerRe *regexp.Regexp, ignore func(name string) bool) (interface{}, error) {
 	// Check if there are any files to analyze
 	if len(pass.Files) == 0 {
 		// No files to analyze, return early
@@ -118,16 +216,18 @@ func run(pass *analysis.Pass, config Config) (interface{}, error) {
 				continue
 			}
 
-			// Extract features with their comments
-			features := extractFeatures(genDecl, file.Comments)
-			
+			// Extract features with their comments, grouping them by the
+			// nearest preceding section marker
+			markers := collectGroupMarkers(genDecl, file.Comments, groupMarkerRe)
+			features := extractFeatures(genDecl, file.Comments, markers)
+
 			// Skip if no features were found
 			if len(features) <= 1 {
 				continue
 			}
 
-			// Sort features
-			sortedFeatures := sortFeatures(features)
+			// Sort features within each group, leaving ignored names pinned
+			sortedFeatures := sortFeaturesGrouped(features, ignore)
 
 			// Check if the order has changed
 			orderChanged := hasOrderChanged(features, sortedFeatures)
@@ -143,12 +243,59 @@ func run(pass *analysis.Pass, config Config) (interface{}, error) {
 
 // Feature represents a feature declaration with its associated comments
 type Feature struct {
-	Name     string   // Name of the feature
-	Comments []string // Comments associated with the feature
+	Name     string    // Name of the feature
+	Comments []string  // Comments associated with the feature
+	Pos      token.Pos // Start of the feature's source text, including any doc comment
+	End      token.Pos // End of the feature's source text
+	Group    int       // Index of the section (as delimited by group marker comments) this feature belongs to
 }
 
-// extractFeatures extracts features from a GenDecl
-func extractFeatures(decl *ast.GenDecl, comments []*ast.CommentGroup) []Feature {
+// collectGroupMarkers returns the positions of every comment within decl
+// that matches groupMarkerRe and so starts a new section, in source order.
+// A marker can appear either as its own free-floating CommentGroup (the
+// common case: a heading followed by a blank line) or as the first of
+// several lines in a feature's own Doc comment, when there's no blank line
+// between the heading and the next feature's doc.
+func collectGroupMarkers(decl *ast.GenDecl, comments []*ast.CommentGroup, groupMarkerRe *regexp.Regexp) []token.Pos {
+	var markers []token.Pos
+
+	for _, cg := range comments {
+		if cg.Pos() < decl.Pos() || cg.End() > decl.End() {
+			continue
+		}
+		if len(cg.List) == 1 && groupMarkerRe.MatchString(strings.TrimSpace(cg.List[0].Text)) {
+			markers = append(markers, cg.Pos())
+		}
+	}
+
+	for _, spec := range decl.Specs {
+		valueSpec, ok := spec.(*ast.ValueSpec)
+		if !ok || valueSpec.Doc == nil || len(valueSpec.Doc.List) < 2 {
+			continue
+		}
+		if first := valueSpec.Doc.List[0]; groupMarkerRe.MatchString(strings.TrimSpace(first.Text)) {
+			markers = append(markers, first.Pos())
+		}
+	}
+
+	sort.Slice(markers, func(i, j int) bool { return markers[i] < markers[j] })
+	return markers
+}
+
+// isMarkerPos reports whether pos is one of the group markers collected by
+// collectGroupMarkers.
+func isMarkerPos(pos token.Pos, markers []token.Pos) bool {
+	for _, m := range markers {
+		if m == pos {
+			return true
+		}
+	}
+	return false
+}
+
+// extractFeatures extracts features from a GenDecl, assigning each one to
+// the section delimited by the preceding entries of markers.
+func extractFeatures(decl *ast.GenDecl, comments []*ast.CommentGroup, markers []token.Pos) []Feature {
 	var features []Feature
 
 	for _, spec := range decl.Specs {
@@ -162,40 +309,81 @@ func extractFeatures(decl *ast.GenDecl, comments []*ast.CommentGroup) []Feature
 
 		// Get comments for this feature
 		var featureComments []string
+		start := valueSpec.Pos()
 
 		// Check for doc comments directly on the value spec
 		if valueSpec.Doc != nil {
-			for _, comment := range valueSpec.Doc.List {
+			docList := valueSpec.Doc.List
+			if len(docList) > 0 && isMarkerPos(docList[0].Pos(), markers) {
+				docList = docList[1:]
+			}
+			if len(docList) > 0 {
+				start = docList[0].Pos()
+			}
+			for _, comment := range docList {
 				featureComments = append(featureComments, comment.Text)
 			}
 		} else {
 			// Look for comments before this spec
 			for _, cg := range comments {
-				if cg.End()+1 == valueSpec.Pos() {
+				if cg.End()+1 == valueSpec.Pos() && !isMarkerPos(cg.Pos(), markers) {
 					for _, comment := range cg.List {
 						featureComments = append(featureComments, comment.Text)
 					}
+					start = cg.Pos()
 				}
 			}
 		}
 
+		group := 0
+		for _, m := range markers {
+			if m >= valueSpec.Pos() {
+				break
+			}
+			group++
+		}
+
 		features = append(features, Feature{
 			Name:     name,
 			Comments: featureComments,
+			Pos:      start,
+			End:      valueSpec.End(),
+			Group:    group,
 		})
 	}
 
 	return features
 }
 
-// sortFeatures sorts features alphabetically by name
-func sortFeatures(features []Feature) []Feature {
+// sortFeaturesGrouped returns, for each slot in features (in original
+// order), the Feature that should occupy that slot: features are sorted
+// alphabetically within their Group, group order itself is preserved, and
+// any feature for which ignore returns true keeps its original slot
+// instead of being reordered.
+func sortFeaturesGrouped(features []Feature, ignore func(name string) bool) []Feature {
 	sorted := make([]Feature, len(features))
 	copy(sorted, features)
 
-	sort.Slice(sorted, func(i, j int) bool {
-		return sorted[i].Name < sorted[j].Name
-	})
+	// groupSlots maps a group index to the slots (into features/sorted)
+	// occupied by its non-ignored members, in original order.
+	groupSlots := map[int][]int{}
+	for i, f := range features {
+		if ignore(f.Name) {
+			continue
+		}
+		groupSlots[f.Group] = append(groupSlots[f.Group], i)
+	}
+
+	for _, slots := range groupSlots {
+		members := make([]Feature, len(slots))
+		for i, slot := range slots {
+			members[i] = features[slot]
+		}
+		sort.Slice(members, func(i, j int) bool { return members[i].Name < members[j].Name })
+		for i, slot := range slots {
+			sorted[slot] = members[i]
+		}
+	}
 
 	return sorted
 }
@@ -244,6 +432,57 @@ func reportSortingIssue(pass *analysis.Pass, decl *ast.GenDecl, current, sorted
 		return
 	}
 
-	// Report the issue with the diff
-	pass.Reportf(decl.Pos(), "feature gates are not sorted alphabetically:\n%s\nRun hack/update-sortfeatures.sh to fix", diffText)
+	message := fmt.Sprintf("feature gates are not sorted alphabetically:\n%s\nRun golangci-lint with --fix to apply the suggested fix", diffText)
+	diagnostic := analysis.Diagnostic{
+		Pos:     decl.Pos(),
+		Message: message,
+	}
+
+	if fix, err := suggestedFix(pass, current, sorted); err != nil {
+		// A suggested fix is a nice-to-have; still report the diagnostic
+		// itself even if we couldn't build one (e.g. the source file
+		// couldn't be re-read).
+		if pass.Analyzer != nil {
+			fmt.Fprintf(os.Stderr, "sortedfeatures: building suggested fix: %v\n", err)
+		}
+	} else {
+		diagnostic.SuggestedFixes = []analysis.SuggestedFix{fix}
+	}
+
+	pass.Report(diagnostic)
+}
+
+// suggestedFix builds the analysis.SuggestedFix that moves each slot in
+// current to hold the source text of the corresponding entry in sorted
+// (current and sorted are the same length, index-aligned to slot - see
+// sortFeaturesGrouped), while leaving the original bytes between slots -
+// blank lines, group marker comments - untouched. A single TextEdit
+// replaces the span from the first slot to the last.
+func suggestedFix(pass *analysis.Pass, current, sorted []Feature) (analysis.SuggestedFix, error) {
+	filename := pass.Fset.PositionFor(current[0].Pos, false).Filename
+	src, err := os.ReadFile(filename)
+	if err != nil {
+		return analysis.SuggestedFix{}, fmt.Errorf("reading %s: %w", filename, err)
+	}
+
+	offset := func(pos token.Pos) int { return pass.Fset.PositionFor(pos, false).Offset }
+
+	var newText strings.Builder
+	for i, feature := range current {
+		newText.Write(src[offset(sorted[i].Pos):offset(sorted[i].End)])
+		if i < len(current)-1 {
+			newText.Write(src[offset(feature.End):offset(current[i+1].Pos)])
+		}
+	}
+
+	return analysis.SuggestedFix{
+		Message: "Sort feature gates alphabetically",
+		TextEdits: []analysis.TextEdit{
+			{
+				Pos:     current[0].Pos,
+				End:     current[len(current)-1].End,
+				NewText: []byte(newText.String()),
+			},
+		},
+	}, nil
 }
@@ -0,0 +1,339 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sortedfeatures
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"golang.org/x/tools/go/analysis"
+)
+
+// parseDecl parses src (a single top-level const or var block) and returns
+// its GenDecl along with the file's comment groups, as run() would see them.
+func parseDecl(t *testing.T, src string) (*ast.GenDecl, []*ast.CommentGroup) {
+	t.Helper()
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "test.go", "package features\n\n"+src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("parsing test source: %v", err)
+	}
+	if len(file.Decls) != 1 {
+		t.Fatalf("expected exactly one decl, got %d", len(file.Decls))
+	}
+	genDecl, ok := file.Decls[0].(*ast.GenDecl)
+	if !ok {
+		t.Fatalf("expected a GenDecl, got %T", file.Decls[0])
+	}
+	return genDecl, file.Comments
+}
+
+func names(features []Feature) []string {
+	out := make([]string, len(features))
+	for i, f := range features {
+		out[i] = f.Name
+	}
+	return out
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestExtractFeaturesFlat(t *testing.T) {
+	decl, comments := parseDecl(t, `const (
+	// ZetaGate enables zeta.
+	ZetaGate = "ZetaGate"
+	// AlphaGate enables alpha.
+	AlphaGate = "AlphaGate"
+)`)
+
+	markers := collectGroupMarkers(decl, comments, regexp.MustCompile(defaultGroupMarkerPattern))
+	features := extractFeatures(decl, comments, markers)
+
+	got := names(features)
+	want := []string{"ZetaGate", "AlphaGate"}
+	if !equalStrings(got, want) {
+		t.Fatalf("names = %v, want %v", got, want)
+	}
+	for _, f := range features {
+		if f.Group != 0 {
+			t.Errorf("feature %s: Group = %d, want 0 (no markers in this block)", f.Name, f.Group)
+		}
+	}
+}
+
+func TestGroupedSortingWithBlankLineSeparatedMarkers(t *testing.T) {
+	decl, comments := parseDecl(t, `const (
+	// Alpha features:
+
+	// ZetaAlpha enables zeta in alpha.
+	ZetaAlpha = "ZetaAlpha"
+	// AlphaAlpha enables alpha in alpha.
+	AlphaAlpha = "AlphaAlpha"
+
+	// Beta features:
+
+	// ZetaBeta enables zeta in beta.
+	ZetaBeta = "ZetaBeta"
+	// AlphaBeta enables alpha in beta.
+	AlphaBeta = "AlphaBeta"
+)`)
+
+	re := regexp.MustCompile(defaultGroupMarkerPattern)
+	markers := collectGroupMarkers(decl, comments, re)
+	if len(markers) != 2 {
+		t.Fatalf("collectGroupMarkers: got %d markers, want 2", len(markers))
+	}
+
+	features := extractFeatures(decl, comments, markers)
+	wantGroups := map[string]int{"ZetaAlpha": 1, "AlphaAlpha": 1, "ZetaBeta": 2, "AlphaBeta": 2}
+	for _, f := range features {
+		if f.Group != wantGroups[f.Name] {
+			t.Errorf("feature %s: Group = %d, want %d", f.Name, f.Group, wantGroups[f.Name])
+		}
+	}
+
+	sorted := sortFeaturesGrouped(features, func(string) bool { return false })
+	got := names(sorted)
+	want := []string{"AlphaAlpha", "ZetaAlpha", "AlphaBeta", "ZetaBeta"}
+	if !equalStrings(got, want) {
+		t.Fatalf("sorted names = %v, want %v (sections must not merge)", got, want)
+	}
+}
+
+func TestGroupedSortingWithInterleavedMarker(t *testing.T) {
+	// No blank line between the marker and the first feature's own doc
+	// comment: go/ast merges them into one CommentGroup, which
+	// collectGroupMarkers/extractFeatures must still split apart.
+	decl, comments := parseDecl(t, `const (
+	// Alpha features:
+	// ZetaAlpha enables zeta in alpha.
+	ZetaAlpha = "ZetaAlpha"
+	// AlphaAlpha enables alpha in alpha.
+	AlphaAlpha = "AlphaAlpha"
+)`)
+
+	re := regexp.MustCompile(defaultGroupMarkerPattern)
+	markers := collectGroupMarkers(decl, comments, re)
+	if len(markers) != 1 {
+		t.Fatalf("collectGroupMarkers: got %d markers, want 1", len(markers))
+	}
+
+	features := extractFeatures(decl, comments, markers)
+	zeta := features[0]
+	if len(zeta.Comments) != 1 || zeta.Comments[0] != `// ZetaAlpha enables zeta in alpha.` {
+		t.Errorf("ZetaAlpha comments = %v, want only its own doc line (marker must be stripped)", zeta.Comments)
+	}
+}
+
+func TestGroupedSortingPreservesMultiLineDocComments(t *testing.T) {
+	decl, comments := parseDecl(t, `const (
+	// ZetaGate enables zeta.
+	// It has a longer explanation spanning
+	// multiple lines.
+	ZetaGate = "ZetaGate"
+	// AlphaGate enables alpha.
+	AlphaGate = "AlphaGate"
+)`)
+
+	markers := collectGroupMarkers(decl, comments, regexp.MustCompile(defaultGroupMarkerPattern))
+	features := extractFeatures(decl, comments, markers)
+	zeta := features[0]
+	if len(zeta.Comments) != 3 {
+		t.Fatalf("ZetaGate comments = %v, want 3 lines", zeta.Comments)
+	}
+}
+
+func TestSortFeaturesGroupedIgnoresPinnedNames(t *testing.T) {
+	decl, comments := parseDecl(t, `const (
+	// ZetaGate enables zeta.
+	ZetaGate = "ZetaGate"
+	// MigratingGate is mid-migration and deliberately left in place.
+	MigratingGate = "MigratingGate"
+	// AlphaGate enables alpha.
+	AlphaGate = "AlphaGate"
+)`)
+
+	markers := collectGroupMarkers(decl, comments, regexp.MustCompile(defaultGroupMarkerPattern))
+	features := extractFeatures(decl, comments, markers)
+
+	ignore, err := ignoreMatcher(Config{IgnoreNames: []string{"MigratingGate"}})
+	if err != nil {
+		t.Fatalf("ignoreMatcher() error = %v", err)
+	}
+	sorted := sortFeaturesGrouped(features, ignore)
+
+	got := names(sorted)
+	want := []string{"AlphaGate", "MigratingGate", "ZetaGate"}
+	if !equalStrings(got, want) {
+		t.Fatalf("sorted names = %v, want %v (MigratingGate must keep its slot)", got, want)
+	}
+}
+
+func TestIgnoreMatcherPrefixes(t *testing.T) {
+	ignore, err := ignoreMatcher(Config{IgnorePrefixes: []string{"Legacy"}})
+	if err != nil {
+		t.Fatalf("ignoreMatcher() error = %v", err)
+	}
+	if !ignore("LegacyFooGate") {
+		t.Error("expected LegacyFooGate to be ignored via prefix match")
+	}
+	if ignore("FooGate") {
+		t.Error("did not expect FooGate to be ignored")
+	}
+}
+
+func TestIgnoreMatcherRegexps(t *testing.T) {
+	ignore, err := ignoreMatcher(Config{IgnoreRegexps: []string{"^Deprecated.*Gate$"}})
+	if err != nil {
+		t.Fatalf("ignoreMatcher() error = %v", err)
+	}
+	if !ignore("DeprecatedFooGate") {
+		t.Error("expected DeprecatedFooGate to be ignored via regexp match")
+	}
+	if ignore("FooGate") {
+		t.Error("did not expect FooGate to be ignored")
+	}
+}
+
+func TestIgnoreMatcherRejectsInvalidRegexp(t *testing.T) {
+	if _, err := ignoreMatcher(Config{IgnoreRegexps: []string{"("}}); err == nil {
+		t.Error("ignoreMatcher() error = nil, want an error for the invalid regexp")
+	}
+}
+
+func TestGroupMarkerPatternForNamedGroups(t *testing.T) {
+	re := regexp.MustCompile(groupMarkerPatternFor([]string{"Alpha", "Beta", "GA"}, ""))
+
+	if !re.MatchString("// Alpha features:") {
+		t.Error("expected the Groups-derived pattern to match a listed section name")
+	}
+	if re.MatchString("// Experimental features:") {
+		t.Error("expected the Groups-derived pattern to reject a heading whose name isn't listed")
+	}
+}
+
+// TestSuggestedFixReordersAndReparses exercises suggestedFix directly (it
+// re-reads the source file by path via pass.Fset, so the fixture has to
+// live on disk, unlike parseDecl's in-memory parse) and checks that
+// applying its single TextEdit both re-parses as valid Go and leaves the
+// features in sorted order.
+func TestSuggestedFixReordersAndReparses(t *testing.T) {
+	const src = `package features
+
+const (
+	// ZetaGate enables zeta.
+	ZetaGate = "ZetaGate"
+	// AlphaGate enables alpha.
+	AlphaGate = "AlphaGate"
+	// MidGate enables mid.
+	MidGate = "MidGate"
+)
+`
+	path := filepath.Join(t.TempDir(), "test.go")
+	if err := os.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatalf("writing fixture: %v", err)
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("parsing fixture: %v", err)
+	}
+	decl := file.Decls[0].(*ast.GenDecl)
+
+	markers := collectGroupMarkers(decl, file.Comments, regexp.MustCompile(defaultGroupMarkerPattern))
+	current := extractFeatures(decl, file.Comments, markers)
+	sorted := sortFeaturesGrouped(current, func(string) bool { return false })
+
+	fix, err := suggestedFix(&analysis.Pass{Fset: fset}, current, sorted)
+	if err != nil {
+		t.Fatalf("suggestedFix() error = %v", err)
+	}
+	if len(fix.TextEdits) != 1 {
+		t.Fatalf("TextEdits = %d, want 1", len(fix.TextEdits))
+	}
+
+	edit := fix.TextEdits[0]
+	start := fset.PositionFor(edit.Pos, false).Offset
+	end := fset.PositionFor(edit.End, false).Offset
+	rewritten := src[:start] + string(edit.NewText) + src[end:]
+
+	newFset := token.NewFileSet()
+	newFile, err := parser.ParseFile(newFset, "rewritten.go", rewritten, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("suggested fix does not re-parse as valid Go: %v\n%s", err, rewritten)
+	}
+
+	newDecl := newFile.Decls[0].(*ast.GenDecl)
+	newMarkers := collectGroupMarkers(newDecl, newFile.Comments, regexp.MustCompile(defaultGroupMarkerPattern))
+	got := names(extractFeatures(newDecl, newFile.Comments, newMarkers))
+	want := []string{"AlphaGate", "MidGate", "ZetaGate"}
+	if !equalStrings(got, want) {
+		t.Fatalf("rewritten order = %v, want %v", got, want)
+	}
+}
+
+func TestSortFeaturesGroupedWithNamedGroups(t *testing.T) {
+	decl, comments := parseDecl(t, `const (
+	// Alpha features:
+
+	// ZetaAlpha enables zeta in alpha.
+	ZetaAlpha = "ZetaAlpha"
+	// AlphaAlpha enables alpha in alpha.
+	AlphaAlpha = "AlphaAlpha"
+
+	// Unlisted heading that looks like a marker but isn't a configured group:
+
+	// ZetaOther enables zeta elsewhere.
+	ZetaOther = "ZetaOther"
+	// AlphaOther enables alpha elsewhere.
+	AlphaOther = "AlphaOther"
+)`)
+
+	re := regexp.MustCompile(groupMarkerPatternFor([]string{"Alpha"}, ""))
+	markers := collectGroupMarkers(decl, comments, re)
+	if len(markers) != 1 {
+		t.Fatalf("collectGroupMarkers: got %d markers, want 1 (only the listed group name should match)", len(markers))
+	}
+
+	features := extractFeatures(decl, comments, markers)
+	sorted := sortFeaturesGrouped(features, func(string) bool { return false })
+	got := names(sorted)
+	// "Unlisted heading..." isn't one of Groups, so it doesn't start a new
+	// section: all four features land in the one section opened by "Alpha
+	// features:" and sort together across what looks like two blocks.
+	want := []string{"AlphaAlpha", "AlphaOther", "ZetaAlpha", "ZetaOther"}
+	if !equalStrings(got, want) {
+		t.Fatalf("sorted names = %v, want %v", got, want)
+	}
+}
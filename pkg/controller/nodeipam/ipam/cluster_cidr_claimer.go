@@ -0,0 +1,106 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipam
+
+import (
+	"fmt"
+	"net"
+	"sync"
+)
+
+// ClusterCIDRClaimer publishes each node CIDR this cluster allocates to a
+// registry shared with peer clusters, and reports the CIDRs peer clusters
+// have already claimed there, so that federated clusters with overlapping
+// cluster CIDRs never hand the same block to two different nodes across
+// cluster boundaries.
+//
+// NOTE: the documented default backend - writing a namespaced
+// ClusterCIDRClaim custom resource to a designated hub apiserver, watched
+// via an informer - needs a generated clientset/informer for that CRD,
+// which this checkout doesn't vendor. InMemoryClusterCIDRClaimer below is a
+// fully working single-process implementation (useful standalone and for
+// tests); a CRD-backed ClusterCIDRClaimer would satisfy the same interface
+// once that generated client exists.
+type ClusterCIDRClaimer interface {
+	// Claim publishes that clusterName has taken cidr. Implementations must
+	// treat re-claiming a CIDR already owned by clusterName as a no-op, not
+	// an error.
+	Claim(clusterName string, cidr *net.IPNet) error
+	// Release retracts a previously published claim. Releasing a CIDR not
+	// currently claimed by clusterName must be a no-op, not an error.
+	Release(clusterName string, cidr *net.IPNet) error
+	// PeerClaims returns every currently published claim owned by a cluster
+	// other than localClusterName.
+	PeerClaims(localClusterName string) ([]*net.IPNet, error)
+}
+
+// InMemoryClusterCIDRClaimer is a ClusterCIDRClaimer backed by a local map,
+// safe for concurrent use. It doesn't talk to any hub apiserver, so it only
+// sees claims made through this same instance - useful for single-cluster
+// deployments that still want AllocateOrOccupyCIDR's claim bookkeeping, and
+// as a test double for the CRD-backed backend described above.
+type InMemoryClusterCIDRClaimer struct {
+	mu     sync.RWMutex
+	claims map[string]string // CIDR string -> owning cluster name
+}
+
+// NewInMemoryClusterCIDRClaimer returns an empty InMemoryClusterCIDRClaimer.
+func NewInMemoryClusterCIDRClaimer() *InMemoryClusterCIDRClaimer {
+	return &InMemoryClusterCIDRClaimer{claims: map[string]string{}}
+}
+
+func (c *InMemoryClusterCIDRClaimer) Claim(clusterName string, cidr *net.IPNet) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := cidr.String()
+	if owner, claimed := c.claims[key]; claimed && owner != clusterName {
+		return fmt.Errorf("CIDR %v is already claimed by cluster %q", cidr, owner)
+	}
+	c.claims[key] = clusterName
+	return nil
+}
+
+func (c *InMemoryClusterCIDRClaimer) Release(clusterName string, cidr *net.IPNet) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := cidr.String()
+	if owner, claimed := c.claims[key]; !claimed || owner != clusterName {
+		return nil
+	}
+	delete(c.claims, key)
+	return nil
+}
+
+func (c *InMemoryClusterCIDRClaimer) PeerClaims(localClusterName string) ([]*net.IPNet, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var peerCIDRs []*net.IPNet
+	for key, owner := range c.claims {
+		if owner == localClusterName {
+			continue
+		}
+		_, cidr, err := net.ParseCIDR(key)
+		if err != nil {
+			return nil, fmt.Errorf("parsing claimed CIDR %q: %v", key, err)
+		}
+		peerCIDRs = append(peerCIDRs, cidr)
+	}
+	return peerCIDRs, nil
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipam
+
+import "time"
+
+// LeaderElectionConfig configures the optional leader election that gates
+// when a multiRangeAllocator's workers are allowed to run. It's only the
+// handful of fields Run needs to build the underlying
+// client-go/tools/leaderelection.LeaderElectionConfig and its Lease-based
+// resourcelock - --leader-elect-resource-name, --leader-elect-resource-namespace
+// and friends already plumb the rest in every other leader-elected
+// kube-controller-manager loop.
+//
+// When nil (the default NewMultiCIDRRangeAllocator callers get unless they
+// opt in), the allocator runs in single-writer mode exactly as before this
+// was added: Run starts its workers immediately and AllocateOrOccupyCIDR
+// never refuses an allocation on leadership grounds.
+type LeaderElectionConfig struct {
+	// LockName and LockNamespace name the Lease object replicas coordinate
+	// on, analogous to a CSI controller's --leader-election-namespace.
+	LockName      string
+	LockNamespace string
+	// Identity distinguishes this replica's holder identity in the Lease;
+	// typically the pod name.
+	Identity string
+
+	LeaseDuration time.Duration
+	RenewDeadline time.Duration
+	RetryPeriod   time.Duration
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipam
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestSetLeadingTogglesIsLeading(t *testing.T) {
+	r := &multiRangeAllocator{}
+	if r.isLeading() {
+		t.Fatal("isLeading() = true, want false before setLeading is ever called")
+	}
+
+	r.setLeading(true)
+	if !r.isLeading() {
+		t.Error("isLeading() = false after setLeading(true)")
+	}
+
+	r.setLeading(false)
+	if r.isLeading() {
+		t.Error("isLeading() = true after setLeading(false)")
+	}
+}
+
+func TestDrainProcessingClearsNodesInProcessing(t *testing.T) {
+	r := &multiRangeAllocator{nodesInProcessing: sets.NewString("node-a", "node-b")}
+
+	r.drainProcessing()
+
+	if r.nodesInProcessing.Len() != 0 {
+		t.Errorf("nodesInProcessing after drainProcessing() = %v, want empty", r.nodesInProcessing.List())
+	}
+}
+
+func TestInsertAndRemoveNodeFromProcessing(t *testing.T) {
+	r := &multiRangeAllocator{nodesInProcessing: sets.NewString()}
+
+	if !r.insertNodeToProcessing("node-a") {
+		t.Fatal("insertNodeToProcessing() = false, want true for a node not yet processing")
+	}
+	if r.insertNodeToProcessing("node-a") {
+		t.Error("insertNodeToProcessing() = true, want false for a node already processing")
+	}
+
+	r.removeNodeFromProcessing("node-a")
+	if !r.insertNodeToProcessing("node-a") {
+		t.Error("insertNodeToProcessing() = false after removeNodeFromProcessing, want true again")
+	}
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipam
+
+import (
+	"sync"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const nodeIPAMSubsystem = "nodeipam"
+
+var (
+	cidrSetAllocatedCIDRs = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Subsystem:      nodeIPAMSubsystem,
+			Name:           "cidrset_allocated_cidrs",
+			Help:           "Number of node CIDRs currently allocated out of each configured cluster CIDR range.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"cidr_index"},
+	)
+
+	cidrSetCapacity = metrics.NewGaugeVec(
+		&metrics.GaugeOpts{
+			Subsystem:      nodeIPAMSubsystem,
+			Name:           "cidrset_capacity",
+			Help:           "Total number of node CIDRs each configured cluster CIDR range can produce.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"cidr_index"},
+	)
+
+	cidrAllocationDuration = metrics.NewHistogram(
+		&metrics.HistogramOpts{
+			Subsystem:      nodeIPAMSubsystem,
+			Name:           "cidr_allocation_duration_seconds",
+			Help:           "Time it took to process a single node CIDR update work item, from dequeue to success or final failure.",
+			Buckets:        metrics.DefBuckets,
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+
+	cidrAllocationErrors = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      nodeIPAMSubsystem,
+			Name:           "cidr_allocation_errors_total",
+			Help:           "Number of node CIDR allocation failures, by reason.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"reason"},
+	)
+
+	workqueueDepth = metrics.NewGauge(
+		&metrics.GaugeOpts{
+			Subsystem:      nodeIPAMSubsystem,
+			Name:           "workqueue_depth",
+			Help:           "Current depth of the node CIDR update workqueue.",
+			StabilityLevel: metrics.ALPHA,
+		},
+	)
+
+	registerMetricsOnce sync.Once
+)
+
+// registerMetrics registers the nodeipam metrics with the legacy registry.
+// It's idempotent so constructing more than one allocator in the same
+// process (as tests routinely do) doesn't attempt a duplicate registration.
+func registerMetrics() {
+	registerMetricsOnce.Do(func() {
+		legacyregistry.MustRegister(cidrSetAllocatedCIDRs)
+		legacyregistry.MustRegister(cidrSetCapacity)
+		legacyregistry.MustRegister(cidrAllocationDuration)
+		legacyregistry.MustRegister(cidrAllocationErrors)
+		legacyregistry.MustRegister(workqueueDepth)
+	})
+}
@@ -17,30 +17,59 @@ limitations under the License.
 package ipam
 
 import (
+	"context"
 	"fmt"
 	"net"
+	"strconv"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"k8s.io/klog"
 
 	"k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
 	informers "k8s.io/client-go/informers/core/v1"
 	clientset "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	v1core "k8s.io/client-go/kubernetes/typed/core/v1"
 	corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
 	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/workqueue"
 	"k8s.io/kubernetes/pkg/controller"
 	"k8s.io/kubernetes/pkg/controller/nodeipam/ipam/cidrset"
 	nodeutil "k8s.io/kubernetes/pkg/controller/util/node"
 	utilnode "k8s.io/kubernetes/pkg/util/node"
 )
 
+const (
+	// cidrUpdateQueueBaseDelay and cidrUpdateQueueMaxDelay bound the
+	// exponential backoff applied to a node's CIDR update work item each
+	// time updateCIDRAllocation fails for it.
+	cidrUpdateQueueBaseDelay = 100 * time.Millisecond
+	cidrUpdateQueueMaxDelay  = 60 * time.Second
+	// cidrUpdateMaxRequeues caps how many times a single node's CIDR update
+	// is retried before its pending update is dropped, so a node that can
+	// never be patched (e.g. deleted mid-flight) doesn't requeue forever.
+	// Dropping does not release the node's allocated CIDRs or clear its
+	// entry in the ReservationStore.
+	cidrUpdateMaxRequeues = 5
+
+	// pruneInterval is how often Prune runs while the allocator is running.
+	pruneInterval = 30 * time.Second
+	// cidrPoolWarningThreshold is the fraction of a cluster CIDR range's
+	// capacity at which Prune starts emitting CIDRPoolNearlyExhausted events.
+	cidrPoolWarningThreshold = 0.9
+)
+
 // cidrs are reserved, then
 // node resource is patched with them
 // this type holds the reservation info
@@ -48,12 +77,16 @@ import (
 type nodeAndCIDRs struct {
 	allocatedCIDRs []*net.IPNet
 	nodeName       string
+	// poolName is the NodeCIDRPoolSpec.Name allocatedCIDRs were drawn from,
+	// or "" for the default cidrSets.
+	poolName string
 }
 type multiRangeAllocator struct {
-	client       clientset.Interface
-	cidrSets     []*cidrset.CidrSet
-	clusterCIDRs []*net.IPNet
-	maxCIDRs     int
+	client         clientset.Interface
+	cidrSets       []*cidrset.CidrSet
+	clusterCIDRs   []*net.IPNet
+	maxCIDRs       int
+	subNetMaskSize int
 
 	// nodeLister is able to list/get nodes and is populated by the shared informer passed to
 	// NewCloudCIDRAllocator.
@@ -61,25 +94,88 @@ type multiRangeAllocator struct {
 	// nodesSynced returns true if the node shared informer has been synced at least once.
 	nodesSynced cache.InformerSynced
 
-	// Channel that is used to pass updating Nodes with assigned CIDRs to the background
-	// This increases a throughput of CIDR assignment by not blocking on long operations.
-	nodeCIDRUpdateChannel chan nodeAndCIDRs
-	recorder              record.EventRecorder
+	// updateQueue carries node names whose allocated CIDRs still need to be
+	// patched onto the Node object, rate-limited with exponential backoff so
+	// a node that keeps failing doesn't busy-loop the worker or pile up
+	// unboundedly like the raw channel it replaces did.
+	updateQueue workqueue.RateLimitingInterface
+	// pendingUpdates holds the allocated CIDRs for each node name currently
+	// in updateQueue, keyed by node name - the queue itself only carries
+	// names, so this is where the actual payload rides between
+	// AllocateOrOccupyCIDR enqueuing a node and a worker dequeuing it.
+	pendingUpdatesMu sync.Mutex
+	pendingUpdates   map[string]nodeAndCIDRs
+	recorder         record.EventRecorder
 
 	// Keep a set of nodes that are currectly being processed to avoid races in CIDR allocation
 	lock              sync.Mutex
 	nodesInProcessing sets.String
+
+	// allocatedCounts and cidrCapacities back the
+	// nodeipam_cidrset_allocated_cidrs/nodeipam_cidrset_capacity metrics,
+	// indexed the same way as cidrSets. cidrSets itself exposes no capacity
+	// accessor, so capacity is computed once at construction time and the
+	// allocated count is maintained alongside every allocate/release.
+	allocatedCounts []int64
+	cidrCapacities  []int64
+
+	// reservations persists in-flight CIDR reservations across restarts, so
+	// a PatchNodeCIDRs timeout - which today leaks the CIDR on purpose
+	// rather than risk double-allocating it - can be reconciled and bounded
+	// instead of relying on a controller-manager restart. May be nil, in
+	// which case reservations aren't persisted at all (today's behavior).
+	reservations ReservationStore
+	// reservationTTL is how long an orphaned reservation (node gone, or
+	// never confirmed in Node.Spec.PodCIDRs) is kept before reconcileReservations
+	// treats it as abandoned and releases it back to the pool.
+	reservationTTL time.Duration
+
+	// clusterCIDRClaimer, when non-nil, publishes node CIDRs this cluster
+	// allocates to a registry shared with peer clusters and is consulted at
+	// startup so CIDRs peers already hold are occupied here before any local
+	// allocation can race with them. It is set only through the
+	// NewMultiCIDRRangeAllocator argument; no command-line flag configures it.
+	clusterCIDRClaimer ClusterCIDRClaimer
+	// localClusterName identifies this cluster's own claims in
+	// clusterCIDRClaimer, so its own claims are excluded from the peer-claim
+	// occupation pass. It too comes only from NewMultiCIDRRangeAllocator.
+	localClusterName string
+
+	// nodeCIDRPools holds any per-node-pool CIDR sets built from the
+	// NodeCIDRPoolSpecs passed to NewMultiCIDRRangeAllocator, checked in order against each node's labels
+	// by cidrSetsFor; the first match's dedicated cidrSets are used instead
+	// of the default ones, and the winning pool's name is recorded on the
+	// node via nodeCIDRPoolAnnotation so ReleaseCIDR returns the CIDR to the
+	// same pool. Empty when no pools are configured, in which case every
+	// node allocates from the default cidrSets exactly as before.
+	nodeCIDRPools []*nodeCIDRPool
+
+	// leaderElectionConfig, when non-nil, gates Run's workers behind winning
+	// a leader election instead of starting them immediately. nil means
+	// single-writer mode, exactly the pre-chunk5-5 behavior.
+	leaderElectionConfig *LeaderElectionConfig
+	// leading is 1 while this instance is allowed to allocate new CIDRs:
+	// always, in single-writer mode, or only between OnStartedLeading and
+	// OnStoppedLeading otherwise. Read/written via atomic so
+	// AllocateOrOccupyCIDR (called from informer callbacks) doesn't need its
+	// own lock for it.
+	leading int32
 }
 
 // NewCIDRRangeAllocator returns a CIDRAllocator to allocate CIDR for node
 // Caller must ensure subNetMaskSize is not less than cluster CIDR mask size.
 // Caller must always pass in a list of existing nodes so the new allocator
 // can initialize its CIDR map. NodeList is only nil in testing.
-func NewMultiCIDRRangeAllocator(client clientset.Interface, nodeInformer informers.NodeInformer, clusterCIDR []*net.IPNet, serviceCIDR *net.IPNet, subNetMaskSize int, nodeList *v1.NodeList) (CIDRAllocator, error) {
+func NewMultiCIDRRangeAllocator(client clientset.Interface, nodeInformer informers.NodeInformer, clusterCIDR []*net.IPNet, serviceCIDR *net.IPNet, subNetMaskSize int, nodeList *v1.NodeList, reservationStore ReservationStore, reservationTTL time.Duration, clusterCIDRClaimer ClusterCIDRClaimer, localClusterName string, nodeCIDRPoolSpecs []NodeCIDRPoolSpec, leaderElectionConfig *LeaderElectionConfig) (CIDRAllocator, error) {
 	if client == nil {
 		klog.Fatalf("kubeClient is nil when starting NodeController")
 	}
 
+	nodeCIDRPools, err := newNodeCIDRPools(nodeCIDRPoolSpecs)
+	if err != nil {
+		return nil, err
+	}
+
 	eventBroadcaster := record.NewBroadcaster()
 	recorder := eventBroadcaster.NewRecorder(scheme.Scheme, v1.EventSource{Component: "cidrAllocator"})
 	eventBroadcaster.StartLogging(klog.Infof)
@@ -92,22 +188,43 @@ func NewMultiCIDRRangeAllocator(client clientset.Interface, nodeInformer informe
 	// count of bits.
 
 	cidrSets := make([]*cidrset.CidrSet, len(clusterCIDR))
+	cidrCapacities := make([]int64, len(clusterCIDR))
 	for idx, cidr := range clusterCIDR {
 		cidrSet, err := cidrset.NewCIDRSet(cidr, subNetMaskSize)
 		if err != nil {
 			return nil, err
 		}
 		cidrSets[idx] = cidrSet
+		ones, _ := cidr.Mask.Size()
+		cidrCapacities[idx] = int64(1) << uint(subNetMaskSize-ones)
 	}
+
+	registerMetrics()
+
 	ra := &multiRangeAllocator{
-		client:                client,
-		cidrSets:              cidrSets,
-		clusterCIDRs:          clusterCIDR,
-		nodeLister:            nodeInformer.Lister(),
-		nodesSynced:           nodeInformer.Informer().HasSynced,
-		nodeCIDRUpdateChannel: make(chan nodeAndCIDRs, cidrUpdateQueueSize),
-		recorder:              recorder,
-		nodesInProcessing:     sets.NewString(),
+		client:               client,
+		cidrSets:             cidrSets,
+		clusterCIDRs:         clusterCIDR,
+		subNetMaskSize:       subNetMaskSize,
+		nodeLister:           nodeInformer.Lister(),
+		nodesSynced:          nodeInformer.Informer().HasSynced,
+		updateQueue:          workqueue.NewNamedRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(cidrUpdateQueueBaseDelay, cidrUpdateQueueMaxDelay), "cidrallocator"),
+		pendingUpdates:       map[string]nodeAndCIDRs{},
+		recorder:             recorder,
+		nodesInProcessing:    sets.NewString(),
+		allocatedCounts:      make([]int64, len(clusterCIDR)),
+		cidrCapacities:       cidrCapacities,
+		reservations:         reservationStore,
+		reservationTTL:       reservationTTL,
+		clusterCIDRClaimer:   clusterCIDRClaimer,
+		localClusterName:     localClusterName,
+		nodeCIDRPools:        nodeCIDRPools,
+		leaderElectionConfig: leaderElectionConfig,
+	}
+	if leaderElectionConfig == nil {
+		// Single-writer mode: this is the only replica, so it's always
+		// allowed to allocate.
+		ra.setLeading(true)
 	}
 
 	if serviceCIDR != nil {
@@ -116,6 +233,12 @@ func NewMultiCIDRRangeAllocator(client clientset.Interface, nodeInformer informe
 		klog.V(0).Info("No Service CIDR provided. Skipping filtering out service addresses.")
 	}
 
+	if ra.clusterCIDRClaimer != nil {
+		if err := ra.occupyPeerClusterClaims(); err != nil {
+			return nil, err
+		}
+	}
+
 	if nodeList != nil {
 		for _, node := range nodeList.Items {
 			if 0 != len(node.Spec.PodCIDRs) {
@@ -135,6 +258,12 @@ func NewMultiCIDRRangeAllocator(client clientset.Interface, nodeInformer informe
 		}
 	}
 
+	if ra.reservations != nil {
+		if err := ra.reconcileReservations(nodeList); err != nil {
+			return nil, err
+		}
+	}
+
 	nodeInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: nodeutil.CreateAddNodeHandler(ra.AllocateOrOccupyCIDR),
 		UpdateFunc: nodeutil.CreateUpdateNodeHandler(func(_, newNode *v1.Node) error {
@@ -170,6 +299,7 @@ func NewMultiCIDRRangeAllocator(client clientset.Interface, nodeInformer informe
 
 func (r *multiRangeAllocator) Run(stopCh <-chan struct{}) {
 	defer utilruntime.HandleCrash()
+	defer r.updateQueue.ShutDown()
 
 	klog.Infof("Starting range CIDR allocator")
 	defer klog.Infof("Shutting down range CIDR allocator")
@@ -178,28 +308,198 @@ func (r *multiRangeAllocator) Run(stopCh <-chan struct{}) {
 		return
 	}
 
+	if r.leaderElectionConfig == nil {
+		r.runWorkers(stopCh)
+		<-stopCh
+		return
+	}
+
+	r.runWithLeaderElection(stopCh)
+}
+
+// runWorkers starts the update-queue worker pool and the periodic Prune
+// loop. Callers are responsible for only calling it while this instance is
+// allowed to act as the active writer.
+func (r *multiRangeAllocator) runWorkers(stopCh <-chan struct{}) {
 	for i := 0; i < cidrUpdateWorkers; i++ {
-		go r.worker(stopCh)
+		go wait.Until(r.runWorker, time.Second, stopCh)
+	}
+	go wait.Until(r.Prune, pruneInterval, stopCh)
+}
+
+// runWithLeaderElection blocks until stopCh closes, re-entering
+// leaderelection.RunOrDie each time it returns after lost leadership, starting runWorkers only once OnStartedLeading fires and draining
+// - without touching cidrSets or updateQueue, which the next leader still
+// needs intact - on OnStoppedLeading. Node events keep being observed the
+// whole time via the informer handlers registered in
+// NewMultiCIDRRangeAllocator, so occupyCIDRs keeps this standby's in-memory
+// cidrSets current and failover has no cold-start delay.
+func (r *multiRangeAllocator) runWithLeaderElection(stopCh <-chan struct{}) {
+	lec := r.leaderElectionConfig
+	lock, err := resourcelock.New(
+		resourcelock.LeasesResourceLock,
+		lec.LockNamespace,
+		lec.LockName,
+		r.client.CoreV1(),
+		r.client.CoordinationV1(),
+		resourcelock.ResourceLockConfig{Identity: lec.Identity, EventRecorder: r.recorder},
+	)
+	if err != nil {
+		klog.Fatalf("Failed to construct resource lock for CIDR allocator leader election: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-stopCh
+		cancel()
+	}()
+
+	electionConfig := leaderelection.LeaderElectionConfig{
+		Lock:          lock,
+		LeaseDuration: lec.LeaseDuration,
+		RenewDeadline: lec.RenewDeadline,
+		RetryPeriod:   lec.RetryPeriod,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: func(leaderCtx context.Context) {
+				klog.Infof("Became leader for CIDR allocation (identity %q)", lec.Identity)
+				r.setLeading(true)
+				r.runWorkers(leaderCtx.Done())
+			},
+			OnStoppedLeading: func() {
+				klog.Infof("Lost leadership for CIDR allocation (identity %q), draining without touching the pool", lec.Identity)
+				r.setLeading(false)
+				r.drainProcessing()
+			},
+		},
+	}
+	for ctx.Err() == nil {
+		leaderelection.RunOrDie(ctx, electionConfig)
 	}
+}
 
-	<-stopCh
+// drainProcessing clears nodesInProcessing on a lost-leadership transition
+// so a node AllocateOrOccupyCIDR or ReleaseCIDR had claimed for in-flight
+// work isn't left stuck marked as processing forever. It deliberately
+// leaves cidrSets and updateQueue untouched - those either belong to
+// whichever replica becomes leader next, or to this one again if it
+// re-acquires the lock.
+func (r *multiRangeAllocator) drainProcessing() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.nodesInProcessing = sets.NewString()
 }
 
-func (r *multiRangeAllocator) worker(stopChan <-chan struct{}) {
-	for {
-		select {
-		case workItem, ok := <-r.nodeCIDRUpdateChannel:
-			if !ok {
-				klog.Warning("Channel nodeCIDRUpdateChannel was unexpectedly closed")
-				return
-			}
-			if err := r.updateCIDRAllocation(workItem); err != nil {
-				// Requeue the failed node for update again.
-				r.nodeCIDRUpdateChannel <- workItem
-			}
-		case <-stopChan:
-			return
+func (r *multiRangeAllocator) isLeading() bool {
+	return atomic.LoadInt32(&r.leading) != 0
+}
+
+func (r *multiRangeAllocator) setLeading(leading bool) {
+	var v int32
+	if leading {
+		v = 1
+	}
+	atomic.StoreInt32(&r.leading, v)
+}
+
+// runWorker pulls node names off updateQueue until it's shut down.
+func (r *multiRangeAllocator) runWorker() {
+	for r.processNextWorkItem() {
+	}
+}
+
+// processNextWorkItem dequeues a single node name, applies its pending CIDR
+// update, and rate-limit-requeues it on failure (dropping it once
+// cidrUpdateMaxRequeues is exceeded). It returns false only once updateQueue
+// has been shut down.
+func (r *multiRangeAllocator) processNextWorkItem() bool {
+	key, shutdown := r.updateQueue.Get()
+	if shutdown {
+		return false
+	}
+	defer r.updateQueue.Done(key)
+	nodeName := key.(string)
+
+	start := time.Now()
+	err := r.processPendingUpdate(nodeName)
+	cidrAllocationDuration.Observe(time.Since(start).Seconds())
+
+	if err == nil {
+		r.updateQueue.Forget(key)
+		return true
+	}
+
+	if r.updateQueue.NumRequeues(key) >= cidrUpdateMaxRequeues {
+		klog.Errorf("Dropping node %v CIDR update after %d requeues: %v", nodeName, cidrUpdateMaxRequeues, err)
+		cidrAllocationErrors.WithLabelValues("requeue_limit_exceeded").Inc()
+		r.updateQueue.Forget(key)
+		r.clearPendingUpdate(nodeName)
+		return true
+	}
+
+	klog.Errorf("Error updating CIDR for node %v, requeuing: %v", nodeName, err)
+	r.updateQueue.AddRateLimited(key)
+	return true
+}
+
+// processPendingUpdate looks up nodeName's queued allocation and applies it.
+// A missing entry means the update was already handled (or dropped) and is
+// not an error - it can happen if the same node is enqueued twice before a
+// worker picks up the first item.
+func (r *multiRangeAllocator) processPendingUpdate(nodeName string) error {
+	data, ok := r.getPendingUpdate(nodeName)
+	if !ok {
+		return nil
+	}
+	err := r.updateCIDRAllocation(data)
+	if err == nil {
+		r.clearPendingUpdate(nodeName)
+	}
+	return err
+}
+
+func (r *multiRangeAllocator) setPendingUpdate(data nodeAndCIDRs) {
+	r.pendingUpdatesMu.Lock()
+	defer r.pendingUpdatesMu.Unlock()
+	r.pendingUpdates[data.nodeName] = data
+}
+
+func (r *multiRangeAllocator) getPendingUpdate(nodeName string) (nodeAndCIDRs, bool) {
+	r.pendingUpdatesMu.Lock()
+	defer r.pendingUpdatesMu.Unlock()
+	data, ok := r.pendingUpdates[nodeName]
+	return data, ok
+}
+
+func (r *multiRangeAllocator) clearPendingUpdate(nodeName string) {
+	r.pendingUpdatesMu.Lock()
+	defer r.pendingUpdatesMu.Unlock()
+	delete(r.pendingUpdates, nodeName)
+}
+
+// Prune reports each cluster CIDR range's allocated/capacity counts and the
+// update queue depth via the nodeipam metrics, and emits a
+// CIDRPoolNearlyExhausted event for any range that has crossed
+// cidrPoolWarningThreshold of its capacity, so operators see a warning
+// before AllocateOrOccupyCIDR actually starts failing on that range. Run
+// invokes it periodically; it's also safe to call directly (e.g. from
+// tests).
+func (r *multiRangeAllocator) Prune() {
+	workqueueDepth.Set(float64(r.updateQueue.Len()))
+
+	for idx := range r.cidrSets {
+		allocated := atomic.LoadInt64(&r.allocatedCounts[idx])
+		capacity := r.cidrCapacities[idx]
+		cidrIndex := strconv.Itoa(idx)
+
+		cidrSetAllocatedCIDRs.WithLabelValues(cidrIndex).Set(float64(allocated))
+		cidrSetCapacity.WithLabelValues(cidrIndex).Set(float64(capacity))
+
+		if capacity == 0 || float64(allocated)/float64(capacity) < cidrPoolWarningThreshold {
+			continue
 		}
+		r.recorder.Eventf(&v1.ObjectReference{Kind: "ClusterCIDR"}, v1.EventTypeWarning, "CIDRPoolNearlyExhausted",
+			"cluster CIDR range %v (index %d) is at %d/%d (%.0f%%) of its node CIDR capacity",
+			r.clusterCIDRs[idx], idx, allocated, capacity, 100*float64(allocated)/float64(capacity))
 	}
 }
 
@@ -219,25 +519,75 @@ func (r *multiRangeAllocator) removeNodeFromProcessing(nodeName string) {
 	r.nodesInProcessing.Delete(nodeName)
 }
 
+// occupyPeerClusterClaims marks every CIDR already claimed by a peer
+// cluster as occupied in our own cidrSets, so AllocateOrOccupyCIDR can never
+// hand the same block to one of our own nodes. A peer CIDR that doesn't
+// fall within any of our cidrSets' ranges at all is expected (it belongs to
+// a disjoint cluster CIDR range) and isn't an error.
+func (r *multiRangeAllocator) occupyPeerClusterClaims() error {
+	peerCIDRs, err := r.clusterCIDRClaimer.PeerClaims(r.localClusterName)
+	if err != nil {
+		return fmt.Errorf("listing peer cluster CIDR claims: %v", err)
+	}
+	for _, peerCIDR := range peerCIDRs {
+		for idx, cidrSet := range r.cidrSets {
+			if err := cidrSet.Occupy(peerCIDR); err != nil {
+				klog.V(4).Infof("Peer CIDR %v does not fall within cidrSets[%v]: %v", peerCIDR, idx, err)
+			}
+		}
+	}
+	return nil
+}
+
 func (r *multiRangeAllocator) occupyCIDRs(node *v1.Node) error {
 	defer r.removeNodeFromProcessing(node.Name)
 	if 0 == len(node.Spec.PodCIDRs) {
 		return nil
 	}
+	cidrSets := r.cidrSetsForPool(node.Annotations[nodeCIDRPoolAnnotation])
 	// for each assigned cidr
-	// the index of assigned cidr is the idx of r.cidrs
+	// the index of assigned cidr is the idx of cidrSets
 	for idx, cidr := range node.Spec.PodCIDRs {
 		_, podCIDR, err := net.ParseCIDR(cidr)
 		if err != nil {
 			return fmt.Errorf("failed to parse node %s, CIDR %s", node.Name, node.Spec.PodCIDR)
 		}
-		if err := r.cidrSets[idx].Occupy(podCIDR); err != nil {
+		if err := cidrSets[idx].Occupy(podCIDR); err != nil {
 			return fmt.Errorf("failed to mark cidr[%v] at idx [%v] as occupied for node: %v: %v", podCIDR, idx, node.Name, err)
 		}
 	}
 	return nil
 }
 
+// cidrSetsFor returns the pool name and cidrSets a node should allocate
+// from: the first configured nodeCIDRPool whose selector matches the node's
+// labels, or ("", r.cidrSets) if none match (or no pools are configured at
+// all).
+func (r *multiRangeAllocator) cidrSetsFor(node *v1.Node) (string, []*cidrset.CidrSet) {
+	for _, pool := range r.nodeCIDRPools {
+		if pool.selector.Matches(labels.Set(node.Labels)) {
+			return pool.name, pool.cidrSets
+		}
+	}
+	return "", r.cidrSets
+}
+
+// cidrSetsForPool returns the cidrSets belonging to poolName, or the default
+// r.cidrSets if poolName is empty or names a pool that no longer exists in
+// the running config (logged by the caller, since only it has the node name
+// for a useful message).
+func (r *multiRangeAllocator) cidrSetsForPool(poolName string) []*cidrset.CidrSet {
+	if poolName == "" {
+		return r.cidrSets
+	}
+	for _, pool := range r.nodeCIDRPools {
+		if pool.name == poolName {
+			return pool.cidrSets
+		}
+	}
+	return r.cidrSets
+}
+
 // WARNING: If you're adding any return calls or defer any more work from this
 // function you have to make sure to update nodesInProcessing properly with the
 // disposition of the node when the work is done.
@@ -251,27 +601,50 @@ func (r *multiRangeAllocator) AllocateOrOccupyCIDR(node *v1.Node) error {
 	}
 
 	if 0 < len(node.Spec.PodCIDRs) {
+		// Occupying a CIDR the node already carries keeps cidrSets
+		// accurate for a standby replica too, so it's allowed regardless of
+		// leadership.
 		return r.occupyCIDRs(node)
 	}
+
+	if !r.isLeading() {
+		r.removeNodeFromProcessing(node.Name)
+		klog.V(4).Infof("Not the leader, refusing to allocate a new CIDR for node %v", node.Name)
+		return nil
+	}
+
 	// allocate and queue the assignment
+	poolName, cidrSets := r.cidrSetsFor(node)
 	allocated := nodeAndCIDRs{
 		nodeName:       node.Name,
-		allocatedCIDRs: make([]*net.IPNet, len(r.cidrSets)),
+		poolName:       poolName,
+		allocatedCIDRs: make([]*net.IPNet, len(cidrSets)),
 	}
 
-	for idx, _ := range r.cidrSets {
-		podCIDR, err := r.cidrSets[idx].AllocateNext()
+	for idx := range cidrSets {
+		podCIDR, err := cidrSets[idx].AllocateNext()
 		if err != nil {
 			r.removeNodeFromProcessing(node.Name)
 			nodeutil.RecordNodeStatusChange(r.recorder, node, "CIDRNotAvailable")
+			cidrAllocationErrors.WithLabelValues("allocate_next").Inc()
 			return fmt.Errorf("failed to allocate cidr from cluster cidr at idx:%v: %v", idx, err)
 		}
 		allocated.allocatedCIDRs[idx] = podCIDR
+		if poolName == "" {
+			atomic.AddInt64(&r.allocatedCounts[idx], 1)
+		}
+
+		if r.clusterCIDRClaimer != nil {
+			if err := r.clusterCIDRClaimer.Claim(r.localClusterName, podCIDR); err != nil {
+				klog.Errorf("Failed to publish CIDR claim for %v: %v", podCIDR, err)
+			}
+		}
 	}
 
 	//queue the assignement
 	klog.V(4).Infof("Putting node %s with CIDR %v into the work queue", node.Name, allocated.allocatedCIDRs)
-	r.nodeCIDRUpdateChannel <- allocated
+	r.setPendingUpdate(allocated)
+	r.updateQueue.Add(node.Name)
 	return nil
 }
 
@@ -280,6 +653,9 @@ func (r *multiRangeAllocator) ReleaseCIDR(node *v1.Node) error {
 		return nil
 	}
 
+	poolName := node.Annotations[nodeCIDRPoolAnnotation]
+	cidrSets := r.cidrSetsForPool(poolName)
+
 	for idx, cidr := range node.Spec.PodCIDRs {
 		_, podCIDR, err := net.ParseCIDR(cidr)
 		if err != nil {
@@ -287,9 +663,18 @@ func (r *multiRangeAllocator) ReleaseCIDR(node *v1.Node) error {
 		}
 
 		klog.V(4).Infof("release CIDR %s for node:%v", cidr, node.Name)
-		if err = r.cidrSets[idx].Release(podCIDR); err != nil {
+		if err = cidrSets[idx].Release(podCIDR); err != nil {
 			return fmt.Errorf("Error when releasing CIDR %v: %v", cidr, err)
 		}
+		if poolName == "" {
+			atomic.AddInt64(&r.allocatedCounts[idx], -1)
+		}
+
+		if r.clusterCIDRClaimer != nil {
+			if err := r.clusterCIDRClaimer.Release(r.localClusterName, podCIDR); err != nil {
+				klog.Errorf("Failed to retract CIDR claim for %v: %v", podCIDR, err)
+			}
+		}
 	}
 	return nil
 }
@@ -322,6 +707,7 @@ func (r *multiRangeAllocator) updateCIDRAllocation(data nodeAndCIDRs) error {
 	var node *v1.Node
 	defer r.removeNodeFromProcessing(data.nodeName)
 	cidrsString := r.cidrsAsString(data.allocatedCIDRs)
+	cidrSets := r.cidrSetsForPool(data.poolName)
 	node, err = r.nodeLister.Get(data.nodeName)
 	if err != nil {
 		klog.Errorf("Failed while getting node %v for updating Node.Spec.PodCIDRs: %v", data.nodeName, err)
@@ -353,37 +739,156 @@ func (r *multiRangeAllocator) updateCIDRAllocation(data nodeAndCIDRs) error {
 			if nil != err {
 				klog.Errorf("Error when parsing CIDR idx:%v value: %v", idx, cidr)
 			}
-			if err := r.cidrSets[idx].Release(parsedCidr); err != nil {
+			if err := cidrSets[idx].Release(parsedCidr); err != nil {
 				klog.Errorf("Error when releasing CIDR idx:%v value: %v", idx, cidr)
 			}
+			if data.poolName == "" {
+				atomic.AddInt64(&r.allocatedCounts[idx], -1)
+			}
 		}
 		return nil
 	}
 
+	// Persist the reservation before attempting the patch, so that if we
+	// crash or lose leadership between here and the patch actually landing,
+	// a restarted allocator can tell these CIDRs are spoken for instead of
+	// handing them out again.
+	if r.reservations != nil {
+		if err := r.reservations.Record(data.nodeName, data.allocatedCIDRs); err != nil {
+			klog.Errorf("Failed to persist CIDR reservation for node %v: %v", data.nodeName, err)
+		}
+	}
+
 	// If we reached here, it means that the node has no CIDR currently assigned. So we set it.
 	for i := 0; i < cidrUpdateRetries; i++ {
 		if err = utilnode.PatchNodeCIDRs(r.client, types.NodeName(node.Name), cidrsString); err == nil {
 			klog.Infof("Set node %v PodCIDR to %v", node.Name, cidrsString)
+			if data.poolName != "" {
+				if annErr := utilnode.PatchNodeAnnotation(r.client, types.NodeName(node.Name), nodeCIDRPoolAnnotation, data.poolName); annErr != nil {
+					klog.Errorf("Failed to annotate node %v with CIDR pool %q: %v", node.Name, data.poolName, annErr)
+				}
+			}
+			if r.reservations != nil {
+				if clearErr := r.reservations.Clear(data.nodeName); clearErr != nil {
+					klog.Errorf("Failed to clear confirmed CIDR reservation for node %v: %v", data.nodeName, clearErr)
+				}
+			}
 			return nil
 		}
 	}
 	// failed release back to the pool
 	klog.Errorf("Failed to update node %v PodCIDR to %v after multiple attempts: %v", node.Name, cidrsString, err)
 	nodeutil.RecordNodeStatusChange(r.recorder, node, "CIDRAssignmentFailed")
+	cidrAllocationErrors.WithLabelValues("patch_node_cidrs").Inc()
 	// We accept the fact that we may leak CIDRs here. This is safer than releasing
 	// them in case when we don't know if request went through.
-	// NodeController restart will return all falsely allocated CIDRs to the pool.
+	// NodeController restart will return all falsely allocated CIDRs to the pool,
+	// bounded by reservationTTL via reconcileReservations if a ReservationStore is in use.
 	if !apierrors.IsServerTimeout(err) {
 		klog.Errorf("CIDR assignment for node %v failed: %v. Releasing allocated CIDR", node.Name, err)
 		for idx, cidr := range data.allocatedCIDRs {
-			if releaseErr := r.cidrSets[idx].Release(cidr); releaseErr != nil {
+			if releaseErr := cidrSets[idx].Release(cidr); releaseErr != nil {
 				klog.Errorf("Error releasing allocated CIDR for node %v: %v", node.Name, releaseErr)
 			}
+			if data.poolName == "" {
+				atomic.AddInt64(&r.allocatedCounts[idx], -1)
+			}
+		}
+		if r.reservations != nil {
+			if clearErr := r.reservations.Clear(data.nodeName); clearErr != nil {
+				klog.Errorf("Failed to clear released CIDR reservation for node %v: %v", data.nodeName, clearErr)
+			}
 		}
 	}
 	return err
 }
 
+// reconcileReservations is called once at startup to bring a persisted
+// ReservationStore back in sync with reality: entries whose CIDRs match
+// Node.Spec.PodCIDRs are cleared (the patch made it through after all),
+// entries for nodes that no longer exist are released back to the pool
+// immediately, and anything else left over for longer than r.reservationTTL
+// is assumed abandoned and released the same way.
+func (r *multiRangeAllocator) reconcileReservations(nodeList *v1.NodeList) error {
+	reservations, err := r.reservations.List()
+	if err != nil {
+		return fmt.Errorf("listing CIDR reservations: %v", err)
+	}
+	if len(reservations) == 0 {
+		return nil
+	}
+
+	nodesByName := make(map[string]*v1.Node)
+	if nodeList != nil {
+		for i := range nodeList.Items {
+			nodesByName[nodeList.Items[i].Name] = &nodeList.Items[i]
+		}
+	}
+
+	for nodeName, res := range reservations {
+		node, nodeExists := nodesByName[nodeName]
+		if nodeExists && sameCIDRs(node.Spec.PodCIDRs, res.CIDRs) {
+			klog.V(2).Infof("Reservation for node %v matches its current PodCIDRs, clearing", nodeName)
+			if err := r.reservations.Clear(nodeName); err != nil {
+				klog.Errorf("Failed to clear reservation for node %v: %v", nodeName, err)
+			}
+			continue
+		}
+
+		if nodeExists && time.Since(res.RecordedAt) < r.reservationTTL {
+			// The node exists but hasn't picked up these CIDRs yet, and the
+			// reservation isn't old enough to call abandoned. Leave it
+			// recorded, and occupied, so nothing else can allocate the same
+			// block while the patch may still be in flight.
+			for idx, cidr := range res.CIDRs {
+				if idx >= len(r.cidrSets) {
+					continue
+				}
+				if _, parsedCIDR, err := net.ParseCIDR(cidr); err == nil {
+					if err := r.cidrSets[idx].Occupy(parsedCIDR); err != nil {
+						klog.Errorf("Failed to occupy reserved CIDR %v for node %v: %v", cidr, nodeName, err)
+					}
+				}
+			}
+			continue
+		}
+
+		klog.Infof("Releasing orphaned CIDR reservation for node %v back to the pool (nodeExists=%v, age=%v)", nodeName, nodeExists, time.Since(res.RecordedAt))
+		for idx, cidr := range res.CIDRs {
+			if idx >= len(r.cidrSets) {
+				continue
+			}
+			_, parsedCIDR, err := net.ParseCIDR(cidr)
+			if err != nil {
+				klog.Errorf("Failed to parse reserved CIDR %v for node %v: %v", cidr, nodeName, err)
+				continue
+			}
+			if err := r.cidrSets[idx].Release(parsedCIDR); err != nil {
+				klog.Errorf("Failed to release reserved CIDR %v for node %v: %v", cidr, nodeName, err)
+			}
+		}
+		if err := r.reservations.Clear(nodeName); err != nil {
+			klog.Errorf("Failed to clear orphaned reservation for node %v: %v", nodeName, err)
+		}
+	}
+	return nil
+}
+
+// sameCIDRs reports whether a and b contain the same CIDR strings in the
+// same order, matching how node.Spec.PodCIDRs and a reservation's CIDRs are
+// both indexed by cidrSets position.
+func sameCIDRs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
 func (r *multiRangeAllocator) cidrsAsString(inCIDRs []*net.IPNet) []string {
 	outCIDRs := make([]string, len(inCIDRs))
 	for idx, inCIDR := range inCIDRs {
@@ -0,0 +1,132 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipam
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"k8s.io/kubernetes/pkg/controller/nodeipam/ipam/cidrset"
+)
+
+// nodeCIDRPoolAnnotation records, on every Node a pooled allocation was made
+// for, which NodeCIDRPoolSpec.Name supplied its PodCIDRs, so ReleaseCIDR can
+// return them to that same pool's cidrset.CidrSets instead of the default
+// ones.
+const nodeCIDRPoolAnnotation = "alpha.kubernetes.io/node-cidr-pool"
+
+// NodeCIDRPoolSpec is one entry of a NodeCIDRPoolConfig: a node selector
+// paired with the cluster CIDRs, and their per-node mask sizes, dedicated to
+// nodes that match it - e.g. a larger per-node block for GPU nodes, or a
+// CIDR confined to a single zone.
+type NodeCIDRPoolSpec struct {
+	// Name identifies the pool in the nodeCIDRPoolAnnotation this allocator
+	// writes back onto matching nodes. Must be unique within a config.
+	Name string `json:"name"`
+	// Selector chooses which nodes draw their PodCIDRs from this pool.
+	Selector metav1.LabelSelector `json:"selector"`
+	// ClusterCIDRs are this pool's cluster CIDR ranges, parallel to
+	// NodeMaskSizes.
+	ClusterCIDRs []string `json:"clusterCIDRs"`
+	// NodeMaskSizes are the per-node CIDR mask size for each entry in
+	// ClusterCIDRs.
+	NodeMaskSizes []int `json:"nodeMaskSizes"`
+}
+
+// NodeCIDRPoolConfig is the file format read by LoadNodeCIDRPools: an
+// ordered list of pools. AllocateOrOccupyCIDR matches a node against Pools
+// in order and uses the first match, the same "first matching rule wins"
+// convention as a NetworkPolicy or PodSecurityPolicy list.
+type NodeCIDRPoolConfig struct {
+	Pools []NodeCIDRPoolSpec `json:"pools"`
+}
+
+// LoadNodeCIDRPools reads and validates a NodeCIDRPoolConfig from a JSON
+// file, returning its Pools.
+//
+// NOTE: the request that added per-pool CIDRs also asked for a
+// NodeCIDRPool CRD as an alternative source, watched via an informer so
+// pools can be added without restarting kube-controller-manager. That needs
+// a generated clientset for the CRD, which - like the ClusterCIDRClaim CRD
+// described in cluster_cidr_claimer.go - this checkout doesn't vendor. A
+// CRD-backed loader would populate the same []NodeCIDRPoolSpec this
+// returns.
+func LoadNodeCIDRPools(path string) ([]NodeCIDRPoolSpec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading node CIDR pool config %s: %v", path, err)
+	}
+	var config NodeCIDRPoolConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("parsing node CIDR pool config %s: %v", path, err)
+	}
+
+	seen := make(map[string]bool, len(config.Pools))
+	for _, pool := range config.Pools {
+		if pool.Name == "" {
+			return nil, fmt.Errorf("node CIDR pool config %s: a pool is missing its name", path)
+		}
+		if seen[pool.Name] {
+			return nil, fmt.Errorf("node CIDR pool config %s: pool name %q is repeated", path, pool.Name)
+		}
+		seen[pool.Name] = true
+		if len(pool.ClusterCIDRs) != len(pool.NodeMaskSizes) {
+			return nil, fmt.Errorf("node CIDR pool %q: clusterCIDRs and nodeMaskSizes must be the same length", pool.Name)
+		}
+	}
+	return config.Pools, nil
+}
+
+// nodeCIDRPool is the runtime form of a NodeCIDRPoolSpec: its selector
+// compiled once, and one cidrset.CidrSet per entry in ClusterCIDRs.
+type nodeCIDRPool struct {
+	name     string
+	selector labels.Selector
+	cidrSets []*cidrset.CidrSet
+}
+
+// newNodeCIDRPools builds the runtime nodeCIDRPool for every spec,
+// allocating each pool's dedicated cidrset.CidrSets.
+func newNodeCIDRPools(specs []NodeCIDRPoolSpec) ([]*nodeCIDRPool, error) {
+	pools := make([]*nodeCIDRPool, len(specs))
+	for i, spec := range specs {
+		selector, err := metav1.LabelSelectorAsSelector(&spec.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("node CIDR pool %q: invalid selector: %v", spec.Name, err)
+		}
+
+		cidrSets := make([]*cidrset.CidrSet, len(spec.ClusterCIDRs))
+		for idx, cidrStr := range spec.ClusterCIDRs {
+			_, cidr, err := net.ParseCIDR(cidrStr)
+			if err != nil {
+				return nil, fmt.Errorf("node CIDR pool %q: invalid cluster CIDR %q: %v", spec.Name, cidrStr, err)
+			}
+			cidrSet, err := cidrset.NewCIDRSet(cidr, spec.NodeMaskSizes[idx])
+			if err != nil {
+				return nil, fmt.Errorf("node CIDR pool %q: %v", spec.Name, err)
+			}
+			cidrSets[idx] = cidrSet
+		}
+		pools[i] = &nodeCIDRPool{name: spec.Name, selector: selector, cidrSets: cidrSets}
+	}
+	return pools, nil
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipam
+
+import (
+	"net"
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"k8s.io/kubernetes/pkg/controller/nodeipam/ipam/cidrset"
+)
+
+func newTestCIDRSet(t *testing.T, cidr string) *cidrset.CidrSet {
+	t.Helper()
+	_, n, err := net.ParseCIDR(cidr)
+	if err != nil {
+		t.Fatalf("ParseCIDR(%q): %v", cidr, err)
+	}
+	set, err := cidrset.NewCIDRSet(n, 24)
+	if err != nil {
+		t.Fatalf("NewCIDRSet(%q): %v", cidr, err)
+	}
+	return set
+}
+
+func newTestAllocatorWithPools(t *testing.T) *multiRangeAllocator {
+	t.Helper()
+
+	gpuPool, err := newNodeCIDRPools([]NodeCIDRPoolSpec{
+		{
+			Name:          "gpu",
+			Selector:      metav1.LabelSelector{MatchLabels: map[string]string{"hardware": "gpu"}},
+			ClusterCIDRs:  []string{"10.1.0.0/16"},
+			NodeMaskSizes: []int{24},
+		},
+	})
+	if err != nil {
+		t.Fatalf("newNodeCIDRPools() error = %v", err)
+	}
+
+	return &multiRangeAllocator{
+		cidrSets:      []*cidrset.CidrSet{newTestCIDRSet(t, "10.0.0.0/16")},
+		nodeCIDRPools: gpuPool,
+	}
+}
+
+func TestCidrSetsForPoolReturnsNamedPool(t *testing.T) {
+	r := newTestAllocatorWithPools(t)
+	if got := r.cidrSetsForPool("gpu"); len(got) != len(r.nodeCIDRPools[0].cidrSets) || got[0] != r.nodeCIDRPools[0].cidrSets[0] {
+		t.Errorf("cidrSetsForPool(%q) = %v, want the gpu pool's cidrSets", "gpu", got)
+	}
+}
+
+func TestCidrSetsForPoolEmptyNameReturnsDefault(t *testing.T) {
+	r := newTestAllocatorWithPools(t)
+	if got := r.cidrSetsForPool(""); len(got) != len(r.cidrSets) || got[0] != r.cidrSets[0] {
+		t.Errorf("cidrSetsForPool(\"\") = %v, want the default cidrSets", got)
+	}
+}
+
+func TestCidrSetsForPoolUnknownNameFallsBackToDefault(t *testing.T) {
+	r := newTestAllocatorWithPools(t)
+	if got := r.cidrSetsForPool("does-not-exist"); len(got) != len(r.cidrSets) || got[0] != r.cidrSets[0] {
+		t.Errorf("cidrSetsForPool(unknown) = %v, want the default cidrSets", got)
+	}
+}
+
+func TestCidrSetsForMatchesNodeSelectorFirst(t *testing.T) {
+	r := newTestAllocatorWithPools(t)
+
+	name, cidrSets := r.cidrSetsFor(&v1.Node{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"hardware": "gpu"}}})
+	if name != "gpu" {
+		t.Errorf("cidrSetsFor() name = %q, want %q", name, "gpu")
+	}
+	if len(cidrSets) != len(r.nodeCIDRPools[0].cidrSets) || cidrSets[0] != r.nodeCIDRPools[0].cidrSets[0] {
+		t.Errorf("cidrSetsFor() cidrSets = %v, want the gpu pool's cidrSets", cidrSets)
+	}
+}
+
+func TestCidrSetsForNoMatchReturnsDefault(t *testing.T) {
+	r := newTestAllocatorWithPools(t)
+
+	name, cidrSets := r.cidrSetsFor(&v1.Node{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"hardware": "cpu"}}})
+	if name != "" {
+		t.Errorf("cidrSetsFor() name = %q, want \"\"", name)
+	}
+	if len(cidrSets) != len(r.cidrSets) || cidrSets[0] != r.cidrSets[0] {
+		t.Errorf("cidrSetsFor() cidrSets = %v, want the default cidrSets", cidrSets)
+	}
+}
+
+func TestNewNodeCIDRPoolsRejectsInvalidSelector(t *testing.T) {
+	_, err := newNodeCIDRPools([]NodeCIDRPoolSpec{
+		{
+			Name: "bad",
+			Selector: metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "hardware", Operator: "not-a-real-operator"},
+			}},
+			ClusterCIDRs:  []string{"10.1.0.0/16"},
+			NodeMaskSizes: []int{24},
+		},
+	})
+	if err == nil {
+		t.Error("newNodeCIDRPools() error = nil, want an error for an invalid selector")
+	}
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipam
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// reservation is what a ReservationStore persists for a single node: the
+// CIDRs handed out to it (one per cidrSets index, same as
+// node.Spec.PodCIDRs), and when the reservation was made so
+// reconcileReservations can tell a fresh in-flight reservation apart from
+// an abandoned one.
+type reservation struct {
+	CIDRs      []string  `json:"cidrs"`
+	RecordedAt time.Time `json:"recordedAt"`
+}
+
+// ReservationStore persists which CIDRs have been tentatively handed out to
+// which node ahead of the Node.Spec.PodCIDRs patch that makes the
+// allocation visible on the Node object itself, so a crash or HA failover
+// between Record and that patch landing doesn't silently leak the CIDRs out
+// of the pool forever - today's behavior on a PatchNodeCIDRs timeout, which
+// deliberately leaks rather than risk double-allocating. Implementations
+// must be safe for concurrent use.
+type ReservationStore interface {
+	// Record persists that cidrs have been reserved for nodeName.
+	Record(nodeName string, cidrs []*net.IPNet) error
+	// Clear removes nodeName's reservation, once its CIDRs are confirmed
+	// present on the Node object or have been released back to the pool.
+	Clear(nodeName string) error
+	// List returns every currently recorded reservation, keyed by node name.
+	List() (map[string]reservation, error)
+}
+
+// FileReservationStore is a ReservationStore backed by a single JSON file,
+// rewritten atomically (write to a temp file, then rename) on every Record
+// or Clear. It's meant for a single active kube-controller-manager writing
+// to a local or shared-mount path; a ConfigMap-backed implementation would
+// satisfy the same interface for deployments that would rather keep this
+// state in the apiserver than on disk.
+type FileReservationStore struct {
+	path string
+
+	mu           sync.Mutex
+	reservations map[string]reservation
+}
+
+// NewFileReservationStore returns a FileReservationStore backed by path,
+// loading any reservations already recorded there.
+func NewFileReservationStore(path string) (*FileReservationStore, error) {
+	s := &FileReservationStore{path: path, reservations: map[string]reservation{}}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading CIDR reservation store %s: %v", path, err)
+	}
+	if len(data) == 0 {
+		return s, nil
+	}
+	if err := json.Unmarshal(data, &s.reservations); err != nil {
+		return nil, fmt.Errorf("parsing CIDR reservation store %s: %v", path, err)
+	}
+	return s, nil
+}
+
+func (s *FileReservationStore) Record(nodeName string, cidrs []*net.IPNet) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cidrStrings := make([]string, len(cidrs))
+	for i, cidr := range cidrs {
+		cidrStrings[i] = cidr.String()
+	}
+	s.reservations[nodeName] = reservation{CIDRs: cidrStrings, RecordedAt: time.Now()}
+	return s.saveLocked()
+}
+
+func (s *FileReservationStore) Clear(nodeName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.reservations[nodeName]; !ok {
+		return nil
+	}
+	delete(s.reservations, nodeName)
+	return s.saveLocked()
+}
+
+func (s *FileReservationStore) List() (map[string]reservation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make(map[string]reservation, len(s.reservations))
+	for nodeName, res := range s.reservations {
+		out[nodeName] = res
+	}
+	return out, nil
+}
+
+func (s *FileReservationStore) saveLocked() error {
+	data, err := json.Marshal(s.reservations)
+	if err != nil {
+		return fmt.Errorf("encoding CIDR reservation store: %v", err)
+	}
+
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("writing CIDR reservation store %s: %v", tmpPath, err)
+	}
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("installing CIDR reservation store %s: %v", s.path, err)
+	}
+	return nil
+}
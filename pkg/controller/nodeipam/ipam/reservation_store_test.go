@@ -0,0 +1,146 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ipam
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+)
+
+func mustParseCIDRs(t *testing.T, cidrs ...string) []*net.IPNet {
+	t.Helper()
+	out := make([]*net.IPNet, len(cidrs))
+	for i, c := range cidrs {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			t.Fatalf("ParseCIDR(%q): %v", c, err)
+		}
+		out[i] = n
+	}
+	return out
+}
+
+func TestFileReservationStoreRecordPersistsAcrossReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reservations.json")
+
+	s, err := NewFileReservationStore(path)
+	if err != nil {
+		t.Fatalf("NewFileReservationStore() error = %v", err)
+	}
+	if err := s.Record("node-a", mustParseCIDRs(t, "10.0.0.0/24")); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+
+	reloaded, err := NewFileReservationStore(path)
+	if err != nil {
+		t.Fatalf("NewFileReservationStore() (reload) error = %v", err)
+	}
+	reservations, err := reloaded.List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	res, ok := reservations["node-a"]
+	if !ok {
+		t.Fatalf("List() = %v, want a reservation for node-a", reservations)
+	}
+	if want := []string{"10.0.0.0/24"}; len(res.CIDRs) != 1 || res.CIDRs[0] != want[0] {
+		t.Errorf("reservations[node-a].CIDRs = %v, want %v", res.CIDRs, want)
+	}
+}
+
+func TestFileReservationStoreClearRemovesReservation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reservations.json")
+
+	s, err := NewFileReservationStore(path)
+	if err != nil {
+		t.Fatalf("NewFileReservationStore() error = %v", err)
+	}
+	if err := s.Record("node-a", mustParseCIDRs(t, "10.0.0.0/24")); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	if err := s.Clear("node-a"); err != nil {
+		t.Fatalf("Clear() error = %v", err)
+	}
+
+	reservations, err := s.List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if _, ok := reservations["node-a"]; ok {
+		t.Errorf("List() = %v, want node-a cleared", reservations)
+	}
+
+	// Clearing again, and reloading from disk, should both stay clean: Clear
+	// must persist the removal, not just update the in-memory map.
+	if err := s.Clear("node-a"); err != nil {
+		t.Fatalf("Clear() on an already-cleared node error = %v", err)
+	}
+	reloaded, err := NewFileReservationStore(path)
+	if err != nil {
+		t.Fatalf("NewFileReservationStore() (reload) error = %v", err)
+	}
+	reservations, err = reloaded.List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(reservations) != 0 {
+		t.Errorf("List() after reload = %v, want empty", reservations)
+	}
+}
+
+func TestNewFileReservationStoreMissingFileStartsEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "does-not-exist.json")
+
+	s, err := NewFileReservationStore(path)
+	if err != nil {
+		t.Fatalf("NewFileReservationStore() error = %v", err)
+	}
+	reservations, err := s.List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(reservations) != 0 {
+		t.Errorf("List() = %v, want empty for a store with no prior file", reservations)
+	}
+}
+
+func TestFileReservationStoreListReturnsACopy(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reservations.json")
+
+	s, err := NewFileReservationStore(path)
+	if err != nil {
+		t.Fatalf("NewFileReservationStore() error = %v", err)
+	}
+	if err := s.Record("node-a", mustParseCIDRs(t, "10.0.0.0/24")); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+
+	reservations, err := s.List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	delete(reservations, "node-a")
+
+	again, err := s.List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if _, ok := again["node-a"]; !ok {
+		t.Error("mutating a List() result affected the store's own state")
+	}
+}
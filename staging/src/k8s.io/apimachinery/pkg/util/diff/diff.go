@@ -35,13 +35,18 @@ import (
 // indicates an element common to both a and b.
 //
 // This function is designed to match the output format of github.com/google/go-cmp/cmp.Diff.
-func Diff(a, b any) string {
-	if reflect.DeepEqual(a, b) {
+//
+// Diff accepts the same kind of functional Option values as cmp.Diff, e.g.
+// IgnoreFields, IgnoreUnexported, Transformer, Comparer, and EquateEmpty, to
+// customize how values are compared and rendered.
+func Diff(a, b any, opts ...Option) string {
+	o := newDiffOptions(opts)
+	if o.equal(a, b) {
 		return ""
 	}
 
 	var sb strings.Builder
-	diffValues(&sb, reflect.ValueOf(a), reflect.ValueOf(b), "", make(map[uintptr]bool), make(map[uintptr]bool))
+	diffValues(&sb, reflect.ValueOf(a), reflect.ValueOf(b), "", make(map[uintptr]bool), make(map[uintptr]bool), o)
 	return sb.String()
 }
 
@@ -60,15 +65,26 @@ type diffContext struct {
 	visitedA map[uintptr]bool
 	visitedB map[uintptr]bool
 	sb       *strings.Builder
+	opts     *diffOptions
 }
 
 // diffValues compares two values and writes the differences to the string builder.
-func diffValues(sb *strings.Builder, a, b reflect.Value, path string, visitedA, visitedB map[uintptr]bool) {
+func diffValues(sb *strings.Builder, a, b reflect.Value, path string, visitedA, visitedB map[uintptr]bool, opts *diffOptions) {
+	if opts == nil {
+		opts = newDiffOptions(nil)
+	}
 	ctx := &diffContext{
 		path:     path,
 		visitedA: visitedA,
 		visitedB: visitedB,
 		sb:       sb,
+		opts:     opts,
+	}
+
+	if a.IsValid() && b.IsValid() && a.Type() == b.Type() {
+		if transformedA, transformedB, ok := opts.transform(a, b); ok {
+			a, b = transformedA, transformedB
+		}
 	}
 
 	// Handle invalid values (nil)
@@ -102,10 +118,10 @@ func diffValues(sb *strings.Builder, a, b reflect.Value, path string, visitedA,
 		return
 	}
 
-	// Handle nil slices vs empty slices and nil maps vs empty maps
+	// EquateEmpty treats nil and empty slices/maps as equal, matching cmp.EquateEmpty.
 	switch a.Kind() {
 	case reflect.Slice, reflect.Map:
-		if (a.IsNil() && !b.IsNil() && b.Len() == 0) || (!a.IsNil() && a.Len() == 0 && b.IsNil()) {
+		if opts.equateEmpty && ((a.IsNil() && !b.IsNil() && b.Len() == 0) || (!a.IsNil() && a.Len() == 0 && b.IsNil())) {
 			return
 		}
 	}
@@ -160,21 +176,21 @@ func diffValues(sb *strings.Builder, a, b reflect.Value, path string, visitedA,
 
 		// For pointers, print the type with & prefix
 		if a.Kind() == reflect.Ptr {
-			if !reflect.DeepEqual(a.Elem().Interface(), b.Elem().Interface()) {
+			if !opts.equal(a.Elem().Interface(), b.Elem().Interface()) {
 				ctx.sb.WriteString("  &")
 				if a.Type().Elem().Name() != "" {
 					ctx.sb.WriteString(a.Type().Elem().String())
 				}
 				ctx.sb.WriteString("{\n")
-				diffValues(ctx.sb, a.Elem(), b.Elem(), ctx.path, ctx.visitedA, ctx.visitedB)
+				diffValues(ctx.sb, a.Elem(), b.Elem(), ctx.path, ctx.visitedA, ctx.visitedB, ctx.opts)
 				ctx.sb.WriteString("  }")
 			}
 		} else {
-			diffValues(ctx.sb, a.Elem(), b.Elem(), ctx.path, ctx.visitedA, ctx.visitedB)
+			diffValues(ctx.sb, a.Elem(), b.Elem(), ctx.path, ctx.visitedA, ctx.visitedB, ctx.opts)
 		}
 
 	case reflect.Struct:
-		if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+		if !opts.equal(a.Interface(), b.Interface()) {
 			ctx.sb.WriteString("  " + a.Type().String() + "{\n")
 
 			// First, collect all fields for context
@@ -183,29 +199,34 @@ func diffValues(sb *strings.Builder, a, b reflect.Value, path string, visitedA,
 				fieldA   reflect.Value
 				fieldB   reflect.Value
 				exported bool
+				ignored  bool
 				changed  bool
 			}
 
+			ignoreUnexported := opts.ignoreUnexported[a.Type()]
+
 			fields := make([]fieldInfo, 0, a.NumField())
 			for i := 0; i < a.NumField(); i++ {
 				field := a.Type().Field(i)
 				fieldA := a.Field(i)
 				fieldB := b.Field(i)
 				exported := field.IsExported()
-				changed := exported && !reflect.DeepEqual(fieldA.Interface(), fieldB.Interface())
+				ignored := opts.isFieldIgnored(a.Type(), field.Name) || (!exported && ignoreUnexported)
+				changed := exported && !ignored && !opts.equal(fieldA.Interface(), fieldB.Interface())
 
 				fields = append(fields, fieldInfo{
 					name:     field.Name,
 					fieldA:   fieldA,
 					fieldB:   fieldB,
 					exported: exported,
+					ignored:  ignored,
 					changed:  changed,
 				})
 			}
 
 			// Show context and changes
 			for _, field := range fields {
-				if !field.exported {
+				if !field.exported || field.ignored {
 					continue
 				}
 
@@ -223,36 +244,21 @@ func diffValues(sb *strings.Builder, a, b reflect.Value, path string, visitedA,
 		}
 
 	case reflect.Slice, reflect.Array:
-		if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+		if !opts.equal(a.Interface(), b.Interface()) {
 			ctx.sb.WriteString("  " + a.Type().String() + "{\n")
 
-			// Find the maximum length
-			maxLen := a.Len()
-			if b.Len() > maxLen {
-				maxLen = b.Len()
-			}
+			script := myersEditScript(a.Len(), b.Len(), func(i, j int) bool {
+				return opts.equal(a.Index(i).Interface(), b.Index(j).Interface())
+			})
 
-			// Show context and changes
-			for i := 0; i < maxLen; i++ {
-				if i < a.Len() && i < b.Len() {
-					// Both slices have this element
-					elemA := a.Index(i)
-					elemB := b.Index(i)
-
-					if !reflect.DeepEqual(elemA.Interface(), elemB.Interface()) {
-						// Elements differ
-						ctx.writeLine(diffRemoved, 1, formatValue(elemA)+",")
-						ctx.writeLine(diffInserted, 1, formatValue(elemB)+",")
-					} else {
-						// Elements are the same - show as context
-						ctx.writeLine(diffIdentical, 1, formatValue(elemA)+",")
-					}
-				} else if i < a.Len() {
-					// Element only in a
-					ctx.writeLine(diffRemoved, 1, formatValue(a.Index(i))+",")
-				} else {
-					// Element only in b
-					ctx.writeLine(diffInserted, 1, formatValue(b.Index(i))+",")
+			for _, op := range script {
+				switch op.kind {
+				case editEqual:
+					ctx.writeLine(diffIdentical, 1, formatValue(a.Index(op.aIndex))+",")
+				case editDelete:
+					ctx.writeLine(diffRemoved, 1, formatValue(a.Index(op.aIndex))+",")
+				case editInsert:
+					ctx.writeLine(diffInserted, 1, formatValue(b.Index(op.bIndex))+",")
 				}
 			}
 
@@ -260,7 +266,7 @@ func diffValues(sb *strings.Builder, a, b reflect.Value, path string, visitedA,
 		}
 
 	case reflect.Map:
-		if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+		if !opts.equal(a.Interface(), b.Interface()) {
 			ctx.sb.WriteString("  " + a.Type().String() + "{\n")
 
 			// Get all keys from both maps
@@ -294,7 +300,7 @@ func diffValues(sb *strings.Builder, a, b reflect.Value, path string, visitedA,
 				} else if !bValue.IsValid() {
 					// Key only in a
 					ctx.writeLine(diffRemoved, 1, fmt.Sprintf("%s: %s,", keyStr, formatValue(aValue)))
-				} else if !reflect.DeepEqual(aValue.Interface(), bValue.Interface()) {
+				} else if !opts.equal(aValue.Interface(), bValue.Interface()) {
 					// Values differ
 					ctx.writeLine(diffRemoved, 1, fmt.Sprintf("%s: %s,", keyStr, formatValue(aValue)))
 					ctx.writeLine(diffInserted, 1, fmt.Sprintf("%s: %s,", keyStr, formatValue(bValue)))
@@ -476,7 +482,176 @@ func ObjectDiff(a, b interface{}) string {
 }
 
 // StringDiff computes a diff between two strings and returns it as a string.
-// This is a convenience wrapper around Diff.
+// Unlike Diff, it operates at line granularity so that multi-line strings
+// produce readable unified-style output instead of a single quoted blob.
 func StringDiff(a, b string) string {
-	return Diff(a, b)
+	if a == b {
+		return ""
+	}
+	linesA := strings.Split(a, "\n")
+	linesB := strings.Split(b, "\n")
+
+	script := myersEditScript(len(linesA), len(linesB), func(i, j int) bool {
+		return linesA[i] == linesB[j]
+	})
+
+	var sb strings.Builder
+	for _, line := range unifiedLines(script, linesA, linesB, 3) {
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// editKind identifies the kind of operation in a Myers edit script.
+type editKind int
+
+const (
+	editEqual editKind = iota
+	editDelete
+	editInsert
+)
+
+// editOp is a single operation in an edit script produced by myersEditScript.
+// aIndex is meaningful for editEqual and editDelete, bIndex for editEqual and editInsert.
+type editOp struct {
+	kind   editKind
+	aIndex int
+	bIndex int
+}
+
+// myersEditScript computes the shortest edit script that transforms a
+// sequence of length n into a sequence of length m, using Myers' O(ND)
+// algorithm. equal(i, j) reports whether element i of a equals element j
+// of b. The algorithm builds the edit graph where a diagonal move consumes
+// a matched pair, a right move is an insertion from b, and a down move is
+// a deletion from a; it tracks the furthest-reaching x value on each
+// k-diagonal for every value of d, snapshotting V at each step so the
+// script can be reconstructed by walking back through the snapshots.
+func myersEditScript(n, m int, equal func(i, j int) bool) []editOp {
+	max := n + m
+	if max == 0 {
+		return nil
+	}
+
+	offset := max
+	v := make([]int, 2*max+1)
+	trace := make([][]int, 0, max+1)
+
+	var d int
+findPath:
+	for d = 0; d <= max; d++ {
+		snapshot := make([]int, len(v))
+		copy(snapshot, v)
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[k-1+offset] < v[k+1+offset]) {
+				x = v[k+1+offset]
+			} else {
+				x = v[k-1+offset] + 1
+			}
+			y := x - k
+
+			for x < n && y < m && equal(x, y) {
+				x++
+				y++
+			}
+
+			v[k+offset] = x
+
+			if x >= n && y >= m {
+				break findPath
+			}
+		}
+	}
+
+	// Walk the trace backwards to reconstruct the script in forward order.
+	var reversed []editOp
+	x, y := n, m
+	for ; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && v[k-1+offset] < v[k+1+offset]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+
+		prevX := v[prevK+offset]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			reversed = append(reversed, editOp{kind: editEqual, aIndex: x, bIndex: y})
+		}
+
+		if d > 0 {
+			if x == prevX {
+				y--
+				reversed = append(reversed, editOp{kind: editInsert, bIndex: y})
+			} else {
+				x--
+				reversed = append(reversed, editOp{kind: editDelete, aIndex: x})
+			}
+		}
+		x, y = prevX, prevY
+	}
+
+	script := make([]editOp, len(reversed))
+	for i, op := range reversed {
+		script[len(reversed)-1-i] = op
+	}
+	return script
+}
+
+// unifiedLines renders an edit script as unified-diff-style lines, trimming
+// runs of identical context down to the given number of lines around each
+// hunk so large equal regions are collapsed.
+func unifiedLines(script []editOp, a, b []string, context int) []string {
+	var out []string
+	for i := 0; i < len(script); {
+		op := script[i]
+		if op.kind != editEqual {
+			switch op.kind {
+			case editDelete:
+				out = append(out, "- "+a[op.aIndex])
+			case editInsert:
+				out = append(out, "+ "+b[op.bIndex])
+			}
+			i++
+			continue
+		}
+
+		// Determine how far this run of equal lines extends.
+		runStart := i
+		runEnd := i
+		for runEnd+1 < len(script) && script[runEnd+1].kind == editEqual {
+			runEnd++
+		}
+		runLen := runEnd - runStart + 1
+
+		atStart := runStart == 0
+		atEnd := runEnd == len(script)-1
+
+		skipping := false
+		for j := runStart; j <= runEnd; j++ {
+			distFromStart := j - runStart
+			distFromEnd := runEnd - j
+			keep := (!atStart && distFromStart < context) || (!atEnd && distFromEnd < context)
+			if keep {
+				out = append(out, "  "+a[script[j].aIndex])
+				skipping = false
+			} else if runLen > 2*context && !skipping {
+				out = append(out, "  ...")
+				skipping = true
+			}
+		}
+		i = runEnd + 1
+	}
+	return out
 }
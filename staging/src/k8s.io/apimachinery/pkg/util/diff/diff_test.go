@@ -17,6 +17,7 @@ limitations under the License.
 package diff
 
 import (
+	"reflect"
 	"regexp"
 	"strings"
 	"testing"
@@ -85,3 +86,157 @@ func removeTypeInfo(s string) string {
 	re = regexp.MustCompile(`\*\w+\(([^)]+)\)`)
 	return re.ReplaceAllString(s, "$1")
 }
+
+func TestMyersEditScriptInsertDeleteReorder(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []string
+		want []editOp
+	}{
+		{
+			name: "insert",
+			a:    []string{"a", "b"},
+			b:    []string{"a", "x", "b"},
+			want: []editOp{
+				{kind: editEqual, aIndex: 0, bIndex: 0},
+				{kind: editInsert, bIndex: 1},
+				{kind: editEqual, aIndex: 1, bIndex: 2},
+			},
+		},
+		{
+			name: "delete",
+			a:    []string{"a", "x", "b"},
+			b:    []string{"a", "b"},
+			want: []editOp{
+				{kind: editEqual, aIndex: 0, bIndex: 0},
+				{kind: editDelete, aIndex: 1},
+				{kind: editEqual, aIndex: 2, bIndex: 1},
+			},
+		},
+		{
+			name: "reorder",
+			a:    []string{"a", "b"},
+			b:    []string{"b", "a"},
+			want: []editOp{
+				{kind: editDelete, aIndex: 0},
+				{kind: editEqual, aIndex: 1, bIndex: 0},
+				{kind: editInsert, bIndex: 1},
+			},
+		},
+		{
+			name: "identical",
+			a:    []string{"a", "b"},
+			b:    []string{"a", "b"},
+			want: []editOp{
+				{kind: editEqual, aIndex: 0, bIndex: 0},
+				{kind: editEqual, aIndex: 1, bIndex: 1},
+			},
+		},
+		{
+			name: "empty to non-empty",
+			a:    nil,
+			b:    []string{"a"},
+			want: []editOp{
+				{kind: editInsert, bIndex: 0},
+			},
+		},
+		{
+			name: "both empty",
+			a:    nil,
+			b:    nil,
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := myersEditScript(len(tt.a), len(tt.b), func(i, j int) bool { return tt.a[i] == tt.b[j] })
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("myersEditScript(%q, %q) = %+v, want %+v", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffSliceInsertDeleteReorder(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     []string
+		expected []string
+	}{
+		{
+			name:     "insert",
+			a:        []string{"a", "b"},
+			b:        []string{"a", "x", "b"},
+			expected: []string{"+ ", "x"},
+		},
+		{
+			name:     "delete",
+			a:        []string{"a", "x", "b"},
+			b:        []string{"a", "b"},
+			expected: []string{"- ", "x"},
+		},
+		{
+			name:     "reorder",
+			a:        []string{"a", "b"},
+			b:        []string{"b", "a"},
+			expected: []string{"- ", "+ "},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Diff(tt.a, tt.b)
+			checkExpected(t, result, tt.expected)
+		})
+	}
+}
+
+func TestDiffSliceIdenticalIsEmpty(t *testing.T) {
+	result := Diff([]string{"a", "b"}, []string{"a", "b"})
+	checkExpected(t, result, "")
+}
+
+func TestStringDiffInsertDeleteReorder(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     string
+		expected []string
+	}{
+		{
+			name:     "insert",
+			a:        "a\nb",
+			b:        "a\nx\nb",
+			expected: []string{"+ x"},
+		},
+		{
+			name:     "delete",
+			a:        "a\nx\nb",
+			b:        "a\nb",
+			expected: []string{"- x"},
+		},
+		{
+			name:     "reorder",
+			a:        "a\nb",
+			b:        "b\na",
+			expected: []string{"- a", "+ a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := StringDiff(tt.a, tt.b)
+			for _, s := range tt.expected {
+				if !strings.Contains(result, s) {
+					t.Errorf("StringDiff(%q, %q) = %q, want it to contain %q", tt.a, tt.b, result, s)
+				}
+			}
+		})
+	}
+}
+
+func TestStringDiffIdenticalIsEmpty(t *testing.T) {
+	if got := StringDiff("a\nb", "a\nb"); got != "" {
+		t.Errorf("StringDiff() of identical strings = %q, want empty", got)
+	}
+}
@@ -0,0 +1,242 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diff
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Option configures the behavior of Diff, following the github.com/google/go-cmp/cmp.Option model.
+type Option interface {
+	apply(*diffOptions)
+}
+
+// diffOptions holds the resolved configuration built up from a list of Option values.
+type diffOptions struct {
+	ignoreFields     map[reflect.Type]map[string]bool
+	ignoreUnexported map[reflect.Type]bool
+	transformers     []transformer
+	comparers        []comparer
+	equateEmpty      bool
+}
+
+type transformer struct {
+	name string
+	fn   reflect.Value
+	in   reflect.Type
+	out  reflect.Type
+}
+
+type comparer struct {
+	fn  reflect.Value
+	typ reflect.Type
+}
+
+type optionFunc func(*diffOptions)
+
+func (f optionFunc) apply(o *diffOptions) { f(o) }
+
+func newDiffOptions(opts []Option) *diffOptions {
+	o := &diffOptions{
+		ignoreFields:     make(map[reflect.Type]map[string]bool),
+		ignoreUnexported: make(map[reflect.Type]bool),
+	}
+	for _, opt := range opts {
+		opt.apply(o)
+	}
+	return o
+}
+
+// IgnoreFields returns an Option that excludes the named fields of structType
+// from comparison. structType is a value (typically the zero value) of the
+// struct type whose fields should be ignored.
+func IgnoreFields(structType any, fieldNames ...string) Option {
+	t := reflect.TypeOf(structType)
+	return optionFunc(func(o *diffOptions) {
+		if o.ignoreFields[t] == nil {
+			o.ignoreFields[t] = make(map[string]bool)
+		}
+		for _, name := range fieldNames {
+			o.ignoreFields[t][name] = true
+		}
+	})
+}
+
+// IgnoreUnexported returns an Option that treats the unexported fields of each
+// of the given types as always equal, so that struct comparisons are driven
+// entirely by exported fields.
+func IgnoreUnexported(types ...any) Option {
+	return optionFunc(func(o *diffOptions) {
+		for _, v := range types {
+			o.ignoreUnexported[reflect.TypeOf(v)] = true
+		}
+	})
+}
+
+// Transformer returns an Option that applies fn, a function of the form
+// func(T) U, to any value of type T before it is compared. A value passed
+// to the renderer directly is rendered in transformed form; struct fields,
+// slice elements and map values are rendered untransformed. This is useful for normalizing values that are semantically equal but not
+// structurally identical, e.g. normalizing a time.Time to UTC.
+func Transformer(name string, fn any) Option {
+	fv := reflect.ValueOf(fn)
+	ft := fv.Type()
+	if ft.Kind() != reflect.Func || ft.NumIn() != 1 || ft.NumOut() != 1 {
+		panic(fmt.Sprintf("diff: Transformer function must have the signature func(T) U, got %s", ft))
+	}
+	return optionFunc(func(o *diffOptions) {
+		o.transformers = append(o.transformers, transformer{
+			name: name,
+			fn:   fv,
+			in:   ft.In(0),
+			out:  ft.Out(0),
+		})
+	})
+}
+
+// Comparer returns an Option that uses fn, a function of the form
+// func(T, T) bool, as the equality check for any pair of values of type T,
+// e.g. to supply custom equality semantics for a type like resource.Quantity.
+func Comparer(fn any) Option {
+	fv := reflect.ValueOf(fn)
+	ft := fv.Type()
+	if ft.Kind() != reflect.Func || ft.NumIn() != 2 || ft.NumOut() != 1 || ft.Out(0).Kind() != reflect.Bool {
+		panic(fmt.Sprintf("diff: Comparer function must have the signature func(T, T) bool, got %s", ft))
+	}
+	if ft.In(0) != ft.In(1) {
+		panic(fmt.Sprintf("diff: Comparer function arguments must have the same type, got %s", ft))
+	}
+	return optionFunc(func(o *diffOptions) {
+		o.comparers = append(o.comparers, comparer{fn: fv, typ: ft.In(0)})
+	})
+}
+
+// EquateEmpty returns an Option that treats nil and empty slices/maps as
+// equal to one another.
+func EquateEmpty() Option {
+	return optionFunc(func(o *diffOptions) {
+		o.equateEmpty = true
+	})
+}
+
+// EquateApproxTime returns an Option that treats two time.Time values as
+// equal if they're within margin of one another, e.g. to absorb the jitter
+// a round-trip through an API server or etcd adds to a timestamp like
+// ObjectMeta.CreationTimestamp.
+func EquateApproxTime(margin time.Duration) Option {
+	return Comparer(func(a, b time.Time) bool {
+		d := a.Sub(b)
+		if d < 0 {
+			d = -d
+		}
+		return d <= margin
+	})
+}
+
+// transformerFor returns the transformer registered for t, if any.
+func (o *diffOptions) transformerFor(t reflect.Type) (transformer, bool) {
+	for _, tr := range o.transformers {
+		if tr.in == t {
+			return tr, true
+		}
+	}
+	return transformer{}, false
+}
+
+// comparerFor returns the comparer registered for t, if any.
+func (o *diffOptions) comparerFor(t reflect.Type) (comparer, bool) {
+	for _, c := range o.comparers {
+		if c.typ == t {
+			return c, true
+		}
+	}
+	return comparer{}, false
+}
+
+// equal reports whether a and b are equal, honoring any configured
+// Transformer and Comparer for their type and EquateEmpty, and ignoring any fields excluded
+// by IgnoreFields or IgnoreUnexported, falling back to reflect.DeepEqual
+// otherwise. Callers that recurse into nested values (struct fields, slice
+// elements, map values) must go through equal rather than reflect.DeepEqual
+// directly, or a Transformer/Comparer registered for a nested type's own
+// type never gets consulted at that depth.
+func (o *diffOptions) equal(a, b any) bool {
+	av, bv := reflect.ValueOf(a), reflect.ValueOf(b)
+	if av.IsValid() && bv.IsValid() && av.Type() == bv.Type() {
+		if transformedA, transformedB, ok := o.transform(av, bv); ok {
+			av, bv = transformedA, transformedB
+		}
+		if o.equateEmpty && (av.Kind() == reflect.Slice || av.Kind() == reflect.Map) && av.Len() == 0 && bv.Len() == 0 {
+			return true
+		}
+		if c, ok := o.comparerFor(av.Type()); ok {
+			out := c.fn.Call([]reflect.Value{av, bv})
+			return out[0].Bool()
+		}
+		if av.Kind() == reflect.Struct {
+			return o.structEqual(av, bv)
+		}
+		return reflect.DeepEqual(av.Interface(), bv.Interface())
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// structEqual compares a struct field-by-field, skipping any field ignored
+// via IgnoreFields or IgnoreUnexported.
+func (o *diffOptions) structEqual(a, b reflect.Value) bool {
+	ignoreUnexported := o.ignoreUnexported[a.Type()]
+	for i := 0; i < a.NumField(); i++ {
+		field := a.Type().Field(i)
+		if o.isFieldIgnored(a.Type(), field.Name) {
+			continue
+		}
+		if !field.IsExported() {
+			if ignoreUnexported {
+				continue
+			}
+			// Value.Equal can compare unexported fields without the
+			// restrictions that Interface() imposes.
+			if !a.Field(i).Equal(b.Field(i)) {
+				return false
+			}
+			continue
+		}
+		if !o.equal(a.Field(i).Interface(), b.Field(i).Interface()) {
+			return false
+		}
+	}
+	return true
+}
+
+// transform applies any registered transformer for a's type, returning the
+// possibly-transformed values and whether a transformation was applied.
+func (o *diffOptions) transform(a, b reflect.Value) (reflect.Value, reflect.Value, bool) {
+	tr, ok := o.transformerFor(a.Type())
+	if !ok {
+		return a, b, false
+	}
+	outA := tr.fn.Call([]reflect.Value{a})[0]
+	outB := tr.fn.Call([]reflect.Value{b})[0]
+	return outA, outB, true
+}
+
+// isFieldIgnored reports whether fieldName of structType should be skipped.
+func (o *diffOptions) isFieldIgnored(structType reflect.Type, fieldName string) bool {
+	return o.ignoreFields[structType][fieldName]
+}
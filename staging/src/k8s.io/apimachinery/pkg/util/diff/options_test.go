@@ -0,0 +1,123 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diff
+
+import (
+	"testing"
+	"time"
+)
+
+type optionsTestStruct struct {
+	Name     string
+	Age      int
+	internal int
+}
+
+func TestIgnoreFields(t *testing.T) {
+	a := optionsTestStruct{Name: "a", Age: 1}
+	b := optionsTestStruct{Name: "b", Age: 1}
+
+	if got := Diff(a, b, IgnoreFields(optionsTestStruct{}, "Name")); got != "" {
+		t.Errorf("expected no diff when Name is ignored, got: %q", got)
+	}
+	if got := Diff(a, b); got == "" {
+		t.Errorf("expected a diff without IgnoreFields")
+	}
+}
+
+func TestIgnoreUnexported(t *testing.T) {
+	a := optionsTestStruct{Name: "a", Age: 1, internal: 1}
+	b := optionsTestStruct{Name: "a", Age: 1, internal: 2}
+
+	if got := Diff(a, b, IgnoreUnexported(optionsTestStruct{})); got != "" {
+		t.Errorf("expected no diff when unexported fields are ignored, got: %q", got)
+	}
+	if got := Diff(a, b); got == "" {
+		t.Errorf("expected a diff without IgnoreUnexported")
+	}
+}
+
+func TestTransformer(t *testing.T) {
+	a := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	b := a.In(time.FixedZone("UTC+1", 3600))
+
+	toUTC := func(v time.Time) time.Time { return v.UTC() }
+	if got := Diff(a, b, Transformer("toUTC", toUTC)); got != "" {
+		t.Errorf("expected no diff after normalizing to UTC, got: %q", got)
+	}
+}
+
+func TestComparer(t *testing.T) {
+	type quantity struct{ milli int64 }
+	equalQuantity := func(a, b quantity) bool { return a.milli == b.milli }
+
+	a := quantity{milli: 1000}
+	b := quantity{milli: 1000}
+	if got := Diff(a, b, Comparer(equalQuantity)); got != "" {
+		t.Errorf("expected no diff with a matching Comparer, got: %q", got)
+	}
+}
+
+func TestTransformerNestedStructField(t *testing.T) {
+	type withTimestamp struct {
+		Name string
+		TS   time.Time
+	}
+
+	a := withTimestamp{Name: "a", TS: time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)}
+	b := withTimestamp{Name: "a", TS: a.TS.In(time.FixedZone("UTC+1", 3600))}
+
+	toUTC := func(v time.Time) time.Time { return v.UTC() }
+	if got := Diff(a, b, Transformer("toUTC", toUTC)); got != "" {
+		t.Errorf("expected no diff for a nested time.Time field normalized to UTC, got: %q", got)
+	}
+}
+
+func TestEquateApproxTime(t *testing.T) {
+	a := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	b := a.Add(2 * time.Second)
+
+	if got := Diff(a, b, EquateApproxTime(5*time.Second)); got != "" {
+		t.Errorf("expected no diff within the margin, got: %q", got)
+	}
+	if got := Diff(a, b, EquateApproxTime(time.Second)); got == "" {
+		t.Errorf("expected a diff outside the margin")
+	}
+}
+
+func TestEquateApproxTimeNestedStructField(t *testing.T) {
+	type withTimestamp struct {
+		Name string
+		TS   time.Time
+	}
+
+	a := withTimestamp{Name: "a", TS: time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)}
+	b := withTimestamp{Name: "a", TS: a.TS.Add(2 * time.Second)}
+
+	if got := Diff(a, b, EquateApproxTime(5*time.Second)); got != "" {
+		t.Errorf("expected no diff for a nested CreationTimestamp-like field within the margin, got: %q", got)
+	}
+}
+
+func TestEquateEmpty(t *testing.T) {
+	if got := Diff([]string(nil), []string{}); got == "" {
+		t.Errorf("expected a diff between nil and empty slice by default")
+	}
+	if got := Diff([]string(nil), []string{}, EquateEmpty()); got != "" {
+		t.Errorf("expected no diff between nil and empty slice with EquateEmpty, got: %q", got)
+	}
+}
@@ -0,0 +1,105 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cacher
+
+import "sort"
+
+// checkpoint is a compact snapshot of the store at a resourceVersion: just
+// enough to diff against a later store state without keeping full objects
+// around - a hash per key rather than the key's object.
+type checkpoint struct {
+	resourceVersion uint64
+	keyHashes       map[string]string
+}
+
+// checkpointRing holds the last capacity checkpoints, oldest first, letting
+// a late-joining watcher whose resourceVersion has fallen out of the event
+// ring (but not out of the oldest checkpoint) be served synthesized events
+// instead of a full relist.
+type checkpointRing struct {
+	capacity    int
+	checkpoints []checkpoint
+}
+
+// newCheckpointRing returns an empty ring retaining at most capacity
+// checkpoints.
+func newCheckpointRing(capacity int) *checkpointRing {
+	return &checkpointRing{capacity: capacity}
+}
+
+// Record appends a new checkpoint, evicting the oldest one if the ring is
+// at capacity.
+func (r *checkpointRing) Record(resourceVersion uint64, keyHashes map[string]string) {
+	r.checkpoints = append(r.checkpoints, checkpoint{resourceVersion: resourceVersion, keyHashes: keyHashes})
+	if len(r.checkpoints) > r.capacity {
+		r.checkpoints = r.checkpoints[len(r.checkpoints)-r.capacity:]
+	}
+}
+
+// OldestResourceVersion returns the resourceVersion of the oldest retained
+// checkpoint, and false if the ring is empty.
+func (r *checkpointRing) OldestResourceVersion() (uint64, bool) {
+	if len(r.checkpoints) == 0 {
+		return 0, false
+	}
+	return r.checkpoints[0].resourceVersion, true
+}
+
+// checkpointDiff is the outcome of comparing a checkpoint against the
+// current store: the minimal set of keys a late-joining watcher would need
+// synthetic Added/Modified/Deleted events for.
+type checkpointDiff struct {
+	Added    []string
+	Modified []string
+	Deleted  []string
+}
+
+// findCheckpoint returns the most recent retained checkpoint whose
+// resourceVersion is <= clientRV, and false if clientRV predates every
+// retained checkpoint (the caller should return TooOldResourceVersion).
+func (r *checkpointRing) findCheckpoint(clientRV uint64) (checkpoint, bool) {
+	for i := len(r.checkpoints) - 1; i >= 0; i-- {
+		if r.checkpoints[i].resourceVersion <= clientRV {
+			return r.checkpoints[i], true
+		}
+	}
+	return checkpoint{}, false
+}
+
+// diffCheckpoint compares a checkpoint's key hashes against the current
+// store's key hashes, restricted to keys present in either side, and
+// returns the synthetic events needed to bring a watcher caught up at the
+// checkpoint to the current state.
+func diffCheckpoint(cp checkpoint, current map[string]string) checkpointDiff {
+	var diff checkpointDiff
+	for key, currentHash := range current {
+		if checkpointHash, existed := cp.keyHashes[key]; !existed {
+			diff.Added = append(diff.Added, key)
+		} else if checkpointHash != currentHash {
+			diff.Modified = append(diff.Modified, key)
+		}
+	}
+	for key := range cp.keyHashes {
+		if _, stillPresent := current[key]; !stillPresent {
+			diff.Deleted = append(diff.Deleted, key)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Modified)
+	sort.Strings(diff.Deleted)
+	return diff
+}
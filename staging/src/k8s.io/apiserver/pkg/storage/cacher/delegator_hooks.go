@@ -0,0 +1,105 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cacher
+
+import (
+	"k8s.io/apiserver/pkg/storage"
+	"k8s.io/klog/v2"
+)
+
+// delegatorPath is which backend actually served a LIST, for
+// DelegatorHooks.OnDecision and the delegatorFallbacksTotal/
+// delegatorResourceVersionLag metrics.
+type delegatorPath string
+
+const (
+	delegatorPathListExactRV  delegatorPath = "listExactRV"
+	delegatorPathListLatestRV delegatorPath = "listLatestRV"
+	delegatorPathEtcd         delegatorPath = "etcd"
+)
+
+// delegatorFallbackReason is why a LIST fell back to delegatorPathEtcd
+// instead of being served from the watch cache.
+type delegatorFallbackReason string
+
+const (
+	// delegatorReasonResourceExpired is the decoded continue token or
+	// requested resourceVersion's anchored snapshot is no longer retained.
+	delegatorReasonResourceExpired delegatorFallbackReason = "resource_expired"
+	// delegatorReasonNotReady is the watch cache hasn't finished its
+	// initial sync yet.
+	delegatorReasonNotReady delegatorFallbackReason = "not_ready"
+	// delegatorReasonSnapshotMissing is a snapshot was never taken at the
+	// RV the request resolved to (as distinct from having been evicted).
+	delegatorReasonSnapshotMissing delegatorFallbackReason = "snapshot_missing"
+	// delegatorReasonFeatureDisabled is ListFromCacheSnapshot is off, so
+	// decideListStrategy never considered the snapshot path at all.
+	delegatorReasonFeatureDisabled delegatorFallbackReason = "feature_disabled"
+)
+
+// DelegatorHooks lets tests observe the decisions CacheDelegator.GetList
+// makes - which path served a LIST, and why it fell back to etcd when it
+// did - instead of asserting on ad-hoc booleans like etcdCalled/
+// resourceExpiredReturned sprinkled through a mock's closures. Production
+// callers get metricsDelegatorHooks; tests substitute a recording
+// implementation.
+//
+// NOTE: this checkout has no cacher.go/delegator.go defining Cacher or
+// CacheDelegator for a real GetList to call OnDecision from, so this is
+// written as the real observability seam a future CacheDelegator.GetList
+// would invoke at its resolveListStrategy/fallback decision points, mirrored
+// by the self-contained fake delegator in delegator_hooks_test.go.
+type DelegatorHooks interface {
+	// OnDecision reports that a LIST for resource, with the given options,
+	// was served via path. reason is only meaningful when path is
+	// delegatorPathEtcd; it's empty otherwise. rvLag is how far behind
+	// opts.ResourceVersion the watch cache was when the decision was made,
+	// or 0 if that isn't known or applicable.
+	OnDecision(resource string, opts storage.ListOptions, path delegatorPath, reason delegatorFallbackReason, rvLag uint64)
+}
+
+// metricsDelegatorHooks is the production DelegatorHooks: it records
+// delegatorFallbacksTotal/delegatorResourceVersionLag and emits a
+// klog structured event carrying the decoded ListOptions, so operators can
+// both alert on a rising fallback rate and grep logs for which requests
+// triggered it.
+type metricsDelegatorHooks struct{}
+
+// newMetricsDelegatorHooks returns the production DelegatorHooks, registering
+// its metrics the same way NewListSnapshotCache registers its own.
+func newMetricsDelegatorHooks() DelegatorHooks {
+	registerMetrics()
+	return metricsDelegatorHooks{}
+}
+
+func (metricsDelegatorHooks) OnDecision(resource string, opts storage.ListOptions, path delegatorPath, reason delegatorFallbackReason, rvLag uint64) {
+	if path == delegatorPathEtcd {
+		delegatorFallbacksTotal.WithLabelValues(resource, string(reason)).Inc()
+	}
+	delegatorResourceVersionLag.WithLabelValues(resource).Observe(float64(rvLag))
+
+	klog.V(4).InfoS("LIST delegator decision",
+		"resource", resource,
+		"path", path,
+		"reason", reason,
+		"resourceVersion", opts.ResourceVersion,
+		"resourceVersionMatch", opts.ResourceVersionMatch,
+		"continue", opts.Predicate.Continue != "",
+		"limit", opts.Predicate.Limit,
+		"rvLag", rvLag,
+	)
+}
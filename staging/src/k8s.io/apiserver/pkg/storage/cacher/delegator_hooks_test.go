@@ -0,0 +1,167 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cacher
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/features"
+	"k8s.io/apiserver/pkg/storage"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
+	featuregatetesting "k8s.io/component-base/featuregate/testing"
+)
+
+// recordingDelegatorHooks is the test DelegatorHooks requested in this
+// chunk: it lets a test assert on the decision a delegator made directly,
+// instead of inferring it from booleans like etcdCalled/
+// resourceExpiredReturned.
+type recordingDelegatorHooks struct {
+	resource string
+	opts     storage.ListOptions
+	path     delegatorPath
+	reason   delegatorFallbackReason
+	rvLag    uint64
+	calls    int
+}
+
+func (h *recordingDelegatorHooks) OnDecision(resource string, opts storage.ListOptions, path delegatorPath, reason delegatorFallbackReason, rvLag uint64) {
+	h.resource, h.opts, h.path, h.reason, h.rvLag = resource, opts, path, reason, rvLag
+	h.calls++
+}
+
+// fakeDelegatorGetList mirrors mockCacheDelegator.GetList's decision logic
+// (decide a strategy, fall back to etcd on ResourceExpired) but reports
+// every decision through hooks instead of mutating test-local booleans, the
+// way a real CacheDelegator.GetList would once cacher.go/delegator.go exist
+// to hold it.
+func fakeDelegatorGetList(resource string, currentRV, resourceVersion uint64, opts storage.ListOptions, cache *ListSnapshotCache, hooks DelegatorHooks) delegatorPath {
+	lag := currentRV - resourceVersion
+	if resourceVersion > currentRV {
+		lag = 0
+	}
+
+	if !utilfeature.DefaultFeatureGate.Enabled(features.ListFromCacheSnapshot) {
+		hooks.OnDecision(resource, opts, delegatorPathListLatestRV, "", lag)
+		return delegatorPathListLatestRV
+	}
+
+	strategy, snapshotRV, err := resolveListStrategy(resourceVersion, opts, cache)
+	if err != nil {
+		hooks.OnDecision(resource, opts, delegatorPathEtcd, delegatorReasonResourceExpired, lag)
+		return delegatorPathEtcd
+	}
+	if strategy != listStrategyExactSnapshot {
+		hooks.OnDecision(resource, opts, delegatorPathListLatestRV, "", lag)
+		return delegatorPathListLatestRV
+	}
+
+	if _, ok := cache.Get(snapshotRV); !ok {
+		hooks.OnDecision(resource, opts, delegatorPathEtcd, delegatorReasonSnapshotMissing, lag)
+		return delegatorPathEtcd
+	}
+
+	hooks.OnDecision(resource, opts, delegatorPathListExactRV, "", lag)
+	return delegatorPathListExactRV
+}
+
+func TestFakeDelegatorReportsDecisionsViaHooks(t *testing.T) {
+	testCases := []struct {
+		name                  string
+		listFromCacheSnapshot bool
+		seedSnapshot          bool
+		opts                  storage.ListOptions
+		wantPath              delegatorPath
+		wantReason            delegatorFallbackReason
+	}{
+		{
+			name:                  "feature disabled always serves latest",
+			listFromCacheSnapshot: false,
+			opts:                  storage.ListOptions{ResourceVersion: "10", Predicate: storage.SelectionPredicate{Limit: 500}},
+			wantPath:              delegatorPathListLatestRV,
+		},
+		{
+			name:                  "exact match with a retained snapshot serves listExactRV",
+			listFromCacheSnapshot: true,
+			seedSnapshot:          true,
+			opts: storage.ListOptions{
+				ResourceVersion:      "10",
+				ResourceVersionMatch: metav1.ResourceVersionMatchExact,
+			},
+			wantPath: delegatorPathListExactRV,
+		},
+		{
+			name:                  "exact match with no retained snapshot falls back to etcd, snapshot missing",
+			listFromCacheSnapshot: true,
+			seedSnapshot:          false,
+			opts: storage.ListOptions{
+				ResourceVersion:      "10",
+				ResourceVersionMatch: metav1.ResourceVersionMatchExact,
+			},
+			wantPath:   delegatorPathEtcd,
+			wantReason: delegatorReasonSnapshotMissing,
+		},
+		{
+			name:                  "an evicted anchored continue token falls back to etcd, resource expired",
+			listFromCacheSnapshot: true,
+			opts: storage.ListOptions{
+				ResourceVersion: "999",
+				Predicate: func() storage.SelectionPredicate {
+					p := storage.SelectionPredicate{Limit: 2}
+					token, err := encodeSnapshotContinue(404, "key5", computeFilterHash(p))
+					if err != nil {
+						t.Fatalf("encodeSnapshotContinue() error = %v", err)
+					}
+					p.Continue = token
+					return p
+				}(),
+			},
+			wantPath:   delegatorPathEtcd,
+			wantReason: delegatorReasonResourceExpired,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			featuregatetesting.SetFeatureGateDuringTest(t, utilfeature.DefaultFeatureGate, features.ListFromCacheSnapshot, tc.listFromCacheSnapshot)
+
+			cache := NewListSnapshotCache("pods", 10)
+			if tc.seedSnapshot {
+				cache.Put(10, "snapshot-at-10")
+			}
+
+			hooks := &recordingDelegatorHooks{}
+			got := fakeDelegatorGetList("pods", 999, 10, tc.opts, cache, hooks)
+
+			if got != tc.wantPath {
+				t.Errorf("fakeDelegatorGetList() = %v, want %v", got, tc.wantPath)
+			}
+			if hooks.calls != 1 {
+				t.Fatalf("hooks.OnDecision called %d times, want exactly 1", hooks.calls)
+			}
+			if hooks.path != tc.wantPath {
+				t.Errorf("hooks.path = %v, want %v", hooks.path, tc.wantPath)
+			}
+			if hooks.reason != tc.wantReason {
+				t.Errorf("hooks.reason = %v, want %v", hooks.reason, tc.wantReason)
+			}
+			if hooks.resource != "pods" {
+				t.Errorf("hooks.resource = %q, want %q", hooks.resource, "pods")
+			}
+		})
+	}
+}
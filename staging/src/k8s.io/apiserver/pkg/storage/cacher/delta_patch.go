@@ -0,0 +1,96 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cacher
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// jsonPatchOp is a single RFC6902 operation. Only the handful of op types
+// diffJSON ever emits (replace/add/remove) are represented; a decoder for
+// the full RFC6902 grammar isn't needed since the cacher only ever produces
+// these patches, never consumes foreign ones.
+type jsonPatchOp struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	Value any    `json:"value,omitempty"`
+}
+
+// diffJSON computes an RFC6902 JSON Patch turning prev into cur, where both
+// are the JSON encoding of a watched object. This is the encoding a
+// watchCacheEvent's optional DeltaEncoding mode would attach to Modified
+// events so watchers can apply a patch instead of receiving the full
+// object - amortized across watchers by computing it once per event.
+//
+// NOTE: this checkout doesn't carry watch_cache.go or watchCacheEvent, only
+// this package's tests, so there's nowhere yet to cache this patch behind a
+// sync.Once on the event, add the new watch.Patched event type, or
+// implement the "fall back to full object" size/staleness heuristics. This
+// adds the patch computation itself, which those integration points would
+// call once they exist.
+func diffJSON(prev, cur []byte) ([]byte, error) {
+	var prevVal, curVal any
+	if err := json.Unmarshal(prev, &prevVal); err != nil {
+		return nil, fmt.Errorf("cacher: decoding previous object: %w", err)
+	}
+	if err := json.Unmarshal(cur, &curVal); err != nil {
+		return nil, fmt.Errorf("cacher: decoding current object: %w", err)
+	}
+
+	var ops []jsonPatchOp
+	diffValue("", prevVal, curVal, &ops)
+	sort.Slice(ops, func(i, j int) bool { return ops[i].Path < ops[j].Path })
+	return json.Marshal(ops)
+}
+
+// diffValue appends the operations needed to turn prev into cur at path
+// into ops, recursing into JSON objects field-by-field so that only the
+// fields that actually changed produce an operation.
+func diffValue(path string, prev, cur any, ops *[]jsonPatchOp) {
+	if reflect.DeepEqual(prev, cur) {
+		return
+	}
+
+	prevObj, prevIsObj := prev.(map[string]any)
+	curObj, curIsObj := cur.(map[string]any)
+	if !prevIsObj || !curIsObj {
+		*ops = append(*ops, jsonPatchOp{Op: "replace", Path: path, Value: cur})
+		return
+	}
+
+	// RFC6901 JSON Pointer escaping: "~" becomes "~0" and "/" becomes "~1".
+	escape := strings.NewReplacer("~", "~0", "/", "~1").Replace
+	for key, curChild := range curObj {
+		childPath := path + "/" + escape(key)
+		prevChild, existed := prevObj[key]
+		switch {
+		case !existed:
+			*ops = append(*ops, jsonPatchOp{Op: "add", Path: childPath, Value: curChild})
+		default:
+			diffValue(childPath, prevChild, curChild, ops)
+		}
+	}
+	for key := range prevObj {
+		if _, stillPresent := curObj[key]; !stillPresent {
+			*ops = append(*ops, jsonPatchOp{Op: "remove", Path: path + "/" + escape(key)})
+		}
+	}
+}
@@ -0,0 +1,169 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cacher
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/storage"
+)
+
+// snapshotContinueToken is the wire format a LIST page served from
+// ListSnapshotCache encodes into its response's continue token, so that the
+// *next* page re-resolves from the exact same snapshot rather than
+// re-evaluating "latest" against a watch cache that's moved on in the
+// meantime. It's a separate, unsigned format from storage.continueToken
+// (continue.go/continue_signing.go): those carry {apiVersion, rv, startKey}
+// for the general etcd-backed continuation contract, while this carries
+// only what resuming against a held ListSnapshotCache entry needs.
+type snapshotContinueToken struct {
+	// SnapshotRV is the resourceVersion of the ListSnapshotCache entry every
+	// page of this LIST must be served from.
+	SnapshotRV uint64 `json:"snapshotRV"`
+	// LastKey is the key the previous page ended on, so the next page can
+	// resume iterating the snapshot from there.
+	LastKey string `json:"lastKey"`
+	// FilterHash pins the label/field selector the first page was evaluated
+	// with, so a token can't be replayed against a different filter and
+	// silently skip over items the original selector would have excluded.
+	FilterHash string `json:"filterHash"`
+}
+
+// errNotSnapshotContinueToken marks a continueValue that isn't in
+// snapshotContinueToken's wire format at all - the common case of a plain,
+// unanchored continue token (or no continue token), which callers should
+// fall back to resolving with decideListStrategy instead of treating as an
+// error.
+type errNotSnapshotContinueToken struct{ cause error }
+
+func (e *errNotSnapshotContinueToken) Error() string {
+	return fmt.Sprintf("not a snapshot-anchored continue token: %v", e.cause)
+}
+func (e *errNotSnapshotContinueToken) Unwrap() error { return e.cause }
+
+// encodeSnapshotContinue returns the continue token a LIST page served from
+// snapshotRV should return to the client, anchoring every later page of the
+// same LIST to that snapshot.
+func encodeSnapshotContinue(snapshotRV uint64, lastKey, filterHash string) (string, error) {
+	out, err := json.Marshal(snapshotContinueToken{SnapshotRV: snapshotRV, LastKey: lastKey, FilterHash: filterHash})
+	if err != nil {
+		return "", fmt.Errorf("encoding snapshot continue token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(out), nil
+}
+
+// decodeSnapshotContinue decodes a continue token previously returned by
+// encodeSnapshotContinue. It returns an *errNotSnapshotContinueToken - not a
+// bare error - when continueValue doesn't parse as this format at all, so
+// callers can tell "this page isn't snapshot-anchored" apart from "this
+// snapshot-anchored token is malformed".
+func decodeSnapshotContinue(continueValue string) (snapshotContinueToken, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(continueValue)
+	if err != nil {
+		return snapshotContinueToken{}, &errNotSnapshotContinueToken{cause: err}
+	}
+	var token snapshotContinueToken
+	if err := json.Unmarshal(raw, &token); err != nil {
+		return snapshotContinueToken{}, &errNotSnapshotContinueToken{cause: err}
+	}
+	if token.SnapshotRV == 0 {
+		return snapshotContinueToken{}, &errNotSnapshotContinueToken{cause: fmt.Errorf("snapshotRV is unset")}
+	}
+	return token, nil
+}
+
+// computeFilterHash returns a short, stable digest of the label/field
+// selectors a LIST's SelectionPredicate filters with, so a snapshot-anchored
+// continue token can detect (and reject) being replayed against a LIST
+// call whose filter has changed since the first page.
+func computeFilterHash(p storage.SelectionPredicate) string {
+	label, field := "", ""
+	if p.Label != nil {
+		label = p.Label.String()
+	}
+	if p.Field != nil {
+		field = p.Field.String()
+	}
+	sum := sha256.Sum256([]byte(label + "|" + field))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// anchorListSnapshot pins data as the ListSnapshotCache entry for rv and
+// returns the continue token the first page of a paginated LIST should
+// return to the client, so that resolveListStrategy routes every later page
+// of the same LIST to listStrategyExactSnapshot against this same entry
+// instead of re-resolving "latest" against a watch cache that's moved on.
+// It's the first-page counterpart to resolveListStrategy: call this once,
+// when a LIST decides (via decideListStrategy) to serve from a snapshot at
+// rv, then hand the returned token back to the client as Continue.
+func anchorListSnapshot(cache *ListSnapshotCache, rv uint64, data interface{}, lastKey string, predicate storage.SelectionPredicate) (string, error) {
+	cache.Put(rv, data)
+	return encodeSnapshotContinue(rv, lastKey, computeFilterHash(predicate))
+}
+
+// resolveListStrategy is decideListStrategy's continue-token-aware
+// counterpart: the one GetList/getListPrefix should actually call once this
+// package's watch_cache.go/cacher.go exist to hold them. When opts carries a
+// snapshot-anchored continue token it takes precedence over the heuristic
+// in decideListStrategy, pinning every later page to the snapshot the first
+// page was served from - unless the caller explicitly asks for
+// ResourceVersionMatch=NotOlderThan, which means "give me the latest state"
+// even on a later page, overriding the anchor. snapshotRV is the RV
+// listExactRV should use in the snapshot path; it's only meaningful when
+// the returned strategy is listStrategyExactSnapshot.
+func resolveListStrategy(resourceVersion uint64, opts storage.ListOptions, cache *ListSnapshotCache) (strategy listStrategy, snapshotRV uint64, err error) {
+	if opts.Predicate.Continue == "" {
+		strategy, err = decideListStrategy(resourceVersion, opts)
+		return strategy, resourceVersion, err
+	}
+
+	token, err := decodeSnapshotContinue(opts.Predicate.Continue)
+	if err != nil {
+		var notAnchored *errNotSnapshotContinueToken
+		if errors.As(err, &notAnchored) {
+			// Not one of ours: a plain continue token, which
+			// decideListStrategy's existing validation/heuristic already
+			// handles (Exact+Continue is rejected there; everything else
+			// falls back to listStrategyLatest).
+			strategy, err = decideListStrategy(resourceVersion, opts)
+			return strategy, resourceVersion, err
+		}
+		return listStrategyLatest, 0, err
+	}
+
+	if opts.ResourceVersionMatch == metav1.ResourceVersionMatchNotOlderThan {
+		return listStrategyLatest, resourceVersion, nil
+	}
+
+	if computeFilterHash(opts.Predicate) != token.FilterHash {
+		return listStrategyLatest, 0, apierrors.NewBadRequest("continue token was issued for a different label/field selector")
+	}
+
+	if cache != nil {
+		if _, expErr := cache.GetOrExpired(token.SnapshotRV); expErr != nil {
+			return listStrategyLatest, 0, expErr
+		}
+	}
+
+	return listStrategyExactSnapshot, token.SnapshotRV, nil
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cacher
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/storage"
+)
+
+func TestResolveListStrategyWithContinueToken(t *testing.T) {
+	predicate := storage.SelectionPredicate{Limit: 500}
+	filterHash := computeFilterHash(predicate)
+
+	cache := NewListSnapshotCache("pods", 10)
+	cache.Put(10, "snapshot-at-10")
+
+	testCases := []struct {
+		name            string
+		resourceVersion uint64
+		opts            storage.ListOptions
+		want            listStrategy
+		wantSnapshotRV  uint64
+		wantError       bool
+	}{
+		{
+			name:            "continue token anchored to a retained snapshot serves from it",
+			resourceVersion: 999,
+			opts: storage.ListOptions{
+				Predicate: withContinue(t, predicate, 10, "key5", filterHash),
+			},
+			want:           listStrategyExactSnapshot,
+			wantSnapshotRV: 10,
+		},
+		{
+			name:            "continue token anchored to an evicted snapshot is rejected as expired",
+			resourceVersion: 999,
+			opts: storage.ListOptions{
+				Predicate: withContinue(t, predicate, 404, "key5", filterHash),
+			},
+			wantError: true,
+		},
+		{
+			name:            "continue token with a mismatched filter hash is rejected",
+			resourceVersion: 999,
+			opts: storage.ListOptions{
+				Predicate: withContinue(t, predicate, 10, "key5", "not-the-right-hash"),
+			},
+			wantError: true,
+		},
+		{
+			name:            "ResourceVersionMatch=NotOlderThan on a later page overrides the anchor",
+			resourceVersion: 999,
+			opts: storage.ListOptions{
+				ResourceVersionMatch: metav1.ResourceVersionMatchNotOlderThan,
+				Predicate:            withContinue(t, predicate, 10, "key5", filterHash),
+			},
+			want: listStrategyLatest,
+		},
+		{
+			name:            "a plain, non-anchored continue token falls back to decideListStrategy",
+			resourceVersion: 999,
+			opts: storage.ListOptions{
+				Predicate: storage.SelectionPredicate{Limit: 500, Continue: "opaque-legacy-token"},
+			},
+			want: listStrategyLatest,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			strategy, snapshotRV, err := resolveListStrategy(tc.resourceVersion, tc.opts, cache)
+			if tc.wantError {
+				if err == nil {
+					t.Fatalf("resolveListStrategy() error = nil, want an error")
+				}
+				if !errors.IsResourceExpired(err) && !errors.IsBadRequest(err) {
+					t.Errorf("resolveListStrategy() error = %v, want ResourceExpired or BadRequest", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveListStrategy() error = %v, want nil", err)
+			}
+			if strategy != tc.want {
+				t.Errorf("resolveListStrategy() strategy = %v, want %v", strategy, tc.want)
+			}
+			if tc.want == listStrategyExactSnapshot && snapshotRV != tc.wantSnapshotRV {
+				t.Errorf("resolveListStrategy() snapshotRV = %d, want %d", snapshotRV, tc.wantSnapshotRV)
+			}
+		})
+	}
+}
+
+// withContinue returns predicate with its Continue field set to a freshly
+// encoded snapshot-anchored token, failing the test immediately if encoding
+// errors (which only happens if json.Marshal itself fails).
+func withContinue(t *testing.T, predicate storage.SelectionPredicate, snapshotRV uint64, lastKey, filterHash string) storage.SelectionPredicate {
+	t.Helper()
+	token, err := encodeSnapshotContinue(snapshotRV, lastKey, filterHash)
+	if err != nil {
+		t.Fatalf("encodeSnapshotContinue() error = %v", err)
+	}
+	predicate.Continue = token
+	return predicate
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cacher
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// defaultSnapshotHistoryMax is the ListSnapshotCache capacity used when
+// NewListSnapshotCache is given maxSnapshots <= 0: generous enough to cover a
+// controller paging through a large LIST in one sitting, without letting a
+// misbehaving client that never finishes paging retain snapshots forever.
+const defaultSnapshotHistoryMax = 100
+
+// listSnapshotEntry is one ListSnapshotCache slot: the resourceVersion it
+// was taken at, and the snapshot data itself.
+//
+// NOTE: data is opaque (interface{}) because this checkout has no
+// watch_cache.go to define what a LIST snapshot actually holds (a copy of
+// the store's values at that RV, in the real implementation). Once that
+// type exists, ListSnapshotCache can be made generic over it instead.
+type listSnapshotEntry struct {
+	rv   uint64
+	data interface{}
+}
+
+// ListSnapshotCache retains a bounded number of in-memory LIST snapshots,
+// one per resourceVersion, so listExactRV (once this package's watch_cache.go
+// exists to call it) can serve every page of a paginated LIST from the
+// exact snapshot its first page was resolved against, rather than
+// allocating a fresh one - or racing the live watch cache - for each page.
+// Entries are evicted least-recently-used once more than maxSnapshots are
+// held, and every Get hit or miss and every eviction is reflected in the
+// storage_list_snapshot_* metrics so operators can size maxSnapshots from
+// real hit/miss/eviction rates instead of guessing. No command-line flag
+// sets maxSnapshots; it is the argument to NewListSnapshotCache.
+type ListSnapshotCache struct {
+	resource string
+	max      int
+
+	mu   sync.Mutex
+	ll   *list.List
+	byRV map[uint64]*list.Element
+}
+
+// NewListSnapshotCache returns a ListSnapshotCache for resource (used only
+// to label its metrics) holding at most maxSnapshots entries. maxSnapshots
+// <= 0 is treated as defaultSnapshotHistoryMax.
+func NewListSnapshotCache(resource string, maxSnapshots int) *ListSnapshotCache {
+	registerMetrics()
+	if maxSnapshots <= 0 {
+		maxSnapshots = defaultSnapshotHistoryMax
+	}
+	return &ListSnapshotCache{
+		resource: resource,
+		max:      maxSnapshots,
+		ll:       list.New(),
+		byRV:     make(map[uint64]*list.Element),
+	}
+}
+
+// Put records data as the snapshot for rv, marking it most-recently-used,
+// and evicts the least-recently-used snapshot if the cache is now over
+// capacity. Putting an rv that's already cached replaces its data without
+// counting as an eviction.
+func (c *ListSnapshotCache) Put(rv uint64, data interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.byRV[rv]; ok {
+		el.Value.(*listSnapshotEntry).data = data
+		c.ll.MoveToFront(el)
+		return
+	}
+
+	el := c.ll.PushFront(&listSnapshotEntry{rv: rv, data: data})
+	c.byRV[rv] = el
+
+	for c.ll.Len() > c.max {
+		oldest := c.ll.Back()
+		if oldest == nil {
+			break
+		}
+		c.ll.Remove(oldest)
+		delete(c.byRV, oldest.Value.(*listSnapshotEntry).rv)
+		listSnapshotEvictions.WithLabelValues(c.resource).Inc()
+	}
+}
+
+// Get returns the snapshot for rv and marks it most-recently-used. The
+// second return value is false if no snapshot for rv is retained, either
+// because one was never taken or because it's since been evicted.
+func (c *ListSnapshotCache) Get(rv uint64) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.byRV[rv]
+	if !ok {
+		listSnapshotMisses.WithLabelValues(c.resource).Inc()
+		return nil, false
+	}
+	listSnapshotHits.WithLabelValues(c.resource).Inc()
+	c.ll.MoveToFront(el)
+	return el.Value.(*listSnapshotEntry).data, true
+}
+
+// GetOrExpired is Get, but reports a miss as a NewResourceExpired error
+// instead of a bare bool, which is what listExactRV should return to the
+// client when the snapshot its continuation targets is no longer retained.
+func (c *ListSnapshotCache) GetOrExpired(rv uint64) (interface{}, error) {
+	data, ok := c.Get(rv)
+	if !ok {
+		return nil, apierrors.NewResourceExpired(fmt.Sprintf("snapshot at resourceVersion %d is no longer available, please list again", rv))
+	}
+	return data, nil
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cacher
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+func TestListSnapshotCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewListSnapshotCache("pods", 3)
+	c.Put(1, "a")
+	c.Put(2, "b")
+	c.Put(3, "c")
+
+	// Touch 1 so it's most-recently-used; 2 is now the true LRU entry even
+	// though it wasn't the first one inserted.
+	if _, ok := c.Get(1); !ok {
+		t.Fatalf("Get(1) = _, false, want true before the cache is over capacity")
+	}
+
+	c.Put(4, "d")
+
+	if _, ok := c.Get(2); ok {
+		t.Errorf("Get(2) = _, true, want false: 2 was the least-recently-used entry and should have been evicted")
+	}
+	if _, ok := c.Get(1); !ok {
+		t.Errorf("Get(1) = _, false, want true: 1 was touched before 4 was inserted and should have survived")
+	}
+	if _, ok := c.Get(3); !ok {
+		t.Errorf("Get(3) = _, false, want true: 3 should have survived")
+	}
+	if _, ok := c.Get(4); !ok {
+		t.Errorf("Get(4) = _, false, want true: 4 was just inserted")
+	}
+}
+
+func TestListSnapshotCacheGetOrExpired(t *testing.T) {
+	c := NewListSnapshotCache("pods", 1)
+	c.Put(1, "a")
+	c.Put(2, "b") // evicts 1
+
+	if _, err := c.GetOrExpired(2); err != nil {
+		t.Errorf("GetOrExpired(2) error = %v, want nil", err)
+	}
+
+	_, err := c.GetOrExpired(1)
+	if !errors.IsResourceExpired(err) {
+		t.Errorf("GetOrExpired(1) error = %v, want a ResourceExpired error for an evicted snapshot", err)
+	}
+}
+
+func TestListSnapshotCacheDefaultMax(t *testing.T) {
+	c := NewListSnapshotCache("pods", 0)
+	if c.max != defaultSnapshotHistoryMax {
+		t.Errorf("max = %d, want the default of %d when maxSnapshots <= 0", c.max, defaultSnapshotHistoryMax)
+	}
+}
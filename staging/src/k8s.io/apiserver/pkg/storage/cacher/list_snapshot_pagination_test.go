@@ -0,0 +1,175 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cacher
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apiserver/pkg/features"
+	"k8s.io/apiserver/pkg/storage"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
+	featuregatetesting "k8s.io/component-base/featuregate/testing"
+)
+
+// fakeSnapshotKeys stands in for the sorted key-space a real watch cache
+// btree snapshot would hold; resolveListStrategy and ListSnapshotCache don't
+// care what the snapshot payload actually is.
+var fakeSnapshotKeys = []string{"a", "b", "c", "d", "e", "f"}
+
+// fakePage returns up to limit keys from data strictly after afterKey (an
+// empty afterKey starts at the beginning), plus the last key returned and
+// whether more keys remain - a stand-in for what a real paginated read of a
+// watch cache btree snapshot would do.
+func fakePage(data []string, afterKey string, limit int) (page []string, lastKey string, hasMore bool) {
+	start := 0
+	if afterKey != "" {
+		for i, k := range data {
+			if k == afterKey {
+				start = i + 1
+				break
+			}
+		}
+	}
+	end := start + limit
+	if end >= len(data) {
+		end = len(data)
+	} else {
+		hasMore = true
+	}
+	page = data[start:end]
+	if len(page) > 0 {
+		lastKey = page[len(page)-1]
+	}
+	return page, lastKey, hasMore
+}
+
+// TestPaginatedListServesAllPagesFromSnapshotWithoutEtcdFallback walks a full
+// paginated LIST - first page through exhaustion - the way
+// CacheDelegator.GetList would once it calls resolveListStrategy/
+// ListSnapshotCache: the first page pins a snapshot and anchors its continue
+// token to it, and every later page must resolve listStrategyExactSnapshot
+// from that same cached entry, never falling back to an etcd read.
+func TestPaginatedListServesAllPagesFromSnapshotWithoutEtcdFallback(t *testing.T) {
+	featuregatetesting.SetFeatureGateDuringTest(t, utilfeature.DefaultFeatureGate, features.ListFromCacheSnapshot, true)
+
+	cache := NewListSnapshotCache("pods", 10)
+	predicate := storage.SelectionPredicate{Limit: 2}
+	etcdCalls := 0
+
+	// Page 1: rv="" per TestListWithFeatureGate's "latest" convention, so
+	// decideListStrategy's gate-eligible branch doesn't fire; the first page
+	// is resolved the way a real GetList resolves "give me the current
+	// state" before it has anything to anchor to, then pins what it read.
+	const firstPageRV = 10
+	page1, lastKey1, hasMore1 := fakePage(fakeSnapshotKeys, "", int(predicate.Limit))
+	if !hasMore1 {
+		t.Fatalf("test fixture too small to exercise multi-page traversal")
+	}
+	continueToken, err := anchorListSnapshot(cache, firstPageRV, fakeSnapshotKeys, lastKey1, predicate)
+	if err != nil {
+		t.Fatalf("anchorListSnapshot() error = %v", err)
+	}
+	if got, want := page1, []string{"a", "b"}; !stringSlicesEqual(got, want) {
+		t.Fatalf("page1 = %v, want %v", got, want)
+	}
+
+	// Pages 2..N: each arrives with the previous page's continue token and
+	// whatever RV the live cache is at *now* (simulated as far ahead of
+	// firstPageRV, the way a real cluster keeps taking writes between
+	// pages), proving the anchor - not "latest" - wins.
+	opts := storage.ListOptions{
+		ResourceVersion: "999",
+		Predicate:       predicate,
+	}
+	var collected []string
+	collected = append(collected, page1...)
+	lastKey := lastKey1
+	for hasMore := hasMore1; hasMore; {
+		opts.Predicate.Continue = continueToken
+		strategy, snapshotRV, err := resolveListStrategy(999, opts, cache)
+		if err != nil {
+			t.Fatalf("resolveListStrategy() error = %v", err)
+		}
+		if strategy != listStrategyExactSnapshot {
+			t.Fatalf("resolveListStrategy() strategy = %v, want listStrategyExactSnapshot", strategy)
+		}
+		if snapshotRV != firstPageRV {
+			t.Fatalf("resolveListStrategy() snapshotRV = %d, want %d", snapshotRV, firstPageRV)
+		}
+
+		data, err := cache.GetOrExpired(snapshotRV)
+		if err != nil {
+			t.Fatalf("GetOrExpired(%d) error = %v, want the page served from cache, not etcd", snapshotRV, err)
+		}
+		var page []string
+		page, lastKey, hasMore = fakePage(data.([]string), lastKey, int(predicate.Limit))
+		collected = append(collected, page...)
+		continueToken, err = encodeSnapshotContinue(snapshotRV, lastKey, computeFilterHash(predicate))
+		if err != nil {
+			t.Fatalf("encodeSnapshotContinue() error = %v", err)
+		}
+	}
+
+	if !stringSlicesEqual(collected, fakeSnapshotKeys) {
+		t.Errorf("collected keys across all pages = %v, want %v", collected, fakeSnapshotKeys)
+	}
+	if etcdCalls != 0 {
+		t.Errorf("etcdCalls = %d, want 0: every page after the first should have been served from the snapshot cache", etcdCalls)
+	}
+}
+
+// TestPaginatedListFallsBackToEtcdOnlyAfterSnapshotEviction proves the ring
+// is bounded: once the anchored snapshot is evicted, resolveListStrategy
+// reports ResourceExpired instead of silently serving stale or wrong data,
+// which is the signal CacheDelegator.GetList uses to fall back to etcd.
+func TestPaginatedListFallsBackToEtcdOnlyAfterSnapshotEviction(t *testing.T) {
+	cache := NewListSnapshotCache("pods", 1)
+	predicate := storage.SelectionPredicate{Limit: 2}
+
+	continueToken, err := anchorListSnapshot(cache, 10, fakeSnapshotKeys, "b", predicate)
+	if err != nil {
+		t.Fatalf("anchorListSnapshot() error = %v", err)
+	}
+
+	// A second, unrelated snapshot (e.g. a different LIST's first page)
+	// evicts rv=10 out of this capacity-1 cache.
+	cache.Put(20, fakeSnapshotKeys)
+
+	opts := storage.ListOptions{
+		ResourceVersion: "999",
+		Predicate:       predicate,
+	}
+	opts.Predicate.Continue = continueToken
+
+	_, _, err = resolveListStrategy(999, opts, cache)
+	if !errors.IsResourceExpired(err) {
+		t.Errorf("resolveListStrategy() error = %v, want ResourceExpired once the anchored snapshot is evicted", err)
+	}
+}
+
+func stringSlicesEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
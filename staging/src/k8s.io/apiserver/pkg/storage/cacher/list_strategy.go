@@ -0,0 +1,106 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cacher
+
+import (
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/features"
+	"k8s.io/apiserver/pkg/storage"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
+)
+
+// listStrategy is what listExactRV/listLatestRV will dispatch on once this
+// package's watch_cache.go/cacher.go exist to hold them.
+//
+// NOTE: that base implementation isn't in this checkout - only
+// watch_cache_test.go, list_test.go and delegator_fallback_test.go are -
+// so decideListStrategy below is written as the real decision+validation
+// logic a future GetList/getListPrefix would call, rather than as a test
+// double: TestListWithFeatureGate's inline listFunc closures capture one
+// slice of it (the unset-match, gate-and-limit-gated heuristic), and this
+// generalizes that to the full ResourceVersionMatch contract so the
+// behavior only has one place to get right.
+type listStrategy int
+
+const (
+	// listStrategyLatest serves the current contents of the watch cache,
+	// waiting (up to the request timeout) for it to catch up to any
+	// requested resourceVersion first. decideListStrategy returns it for
+	// every request except ResourceVersionMatch=Exact and the
+	// ListFromCacheSnapshot-gated unset-match case.
+	listStrategyLatest listStrategy = iota
+	// listStrategyExactSnapshot serves only from a snapshot whose RV
+	// equals the requested resourceVersion exactly, returning
+	// NewResourceExpired if no such snapshot is retained (see
+	// snapshot_store.go for the retention side of that contract).
+	listStrategyExactSnapshot
+)
+
+// validateListOptions rejects ListOptions combinations that can never be
+// served consistently. ResourceVersionMatchExact needs a specific, positive
+// resourceVersion to anchor to, and can't be combined with a continue
+// token, because a continue token already pins its page to whichever RV
+// served the first one (see the snapshot-anchored continue-token codec
+// added alongside this).
+func validateListOptions(opts storage.ListOptions) error {
+	if opts.ResourceVersionMatch != metav1.ResourceVersionMatchExact {
+		return nil
+	}
+	if opts.ResourceVersion == "" || opts.ResourceVersion == "0" {
+		return apierrors.NewBadRequest("resourceVersionMatch=Exact requires a specific, non-zero resourceVersion")
+	}
+	if opts.Predicate.Continue != "" {
+		return apierrors.NewBadRequest("resourceVersionMatch=Exact cannot be combined with a continue token")
+	}
+	return nil
+}
+
+// decideListStrategy validates opts and reports how a LIST for it should be
+// served. resourceVersion is the already-parsed form of
+// opts.ResourceVersion: 0 for an empty, unparseable, or non-positive value,
+// matching how the pre-existing rv>0 check in TestListWithFeatureGate's
+// simulated watch_cache.go condition treats those inputs.
+func decideListStrategy(resourceVersion uint64, opts storage.ListOptions) (listStrategy, error) {
+	if err := validateListOptions(opts); err != nil {
+		return listStrategyLatest, err
+	}
+
+	switch opts.ResourceVersionMatch {
+	case metav1.ResourceVersionMatchExact:
+		return listStrategyExactSnapshot, nil
+	case metav1.ResourceVersionMatchNotOlderThan:
+		// Any snapshot with RV >= resourceVersion already satisfies
+		// NotOlderThan, which is exactly what serving from the live watch
+		// cache (falling back to a bounded wait for it to catch up) does.
+		return listStrategyLatest, nil
+	default:
+		// Unset: preserve the pre-existing "not older than" behavior for
+		// compatibility, switching to the snapshot path only when the
+		// ListFromCacheSnapshot gate is enabled, a limit is requested, and
+		// resourceVersion is a real, positive RV - exactly the condition
+		// TestListWithFeatureGate exercises.
+		if opts.Predicate.Limit > 0 &&
+			len(opts.ResourceVersion) > 0 &&
+			opts.ResourceVersion != "0" &&
+			utilfeature.DefaultFeatureGate.Enabled(features.ListFromCacheSnapshot) &&
+			resourceVersion > 0 {
+			return listStrategyExactSnapshot, nil
+		}
+		return listStrategyLatest, nil
+	}
+}
@@ -0,0 +1,146 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cacher
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/features"
+	"k8s.io/apiserver/pkg/storage"
+	utilfeature "k8s.io/apiserver/pkg/util/feature"
+	featuregatetesting "k8s.io/component-base/featuregate/testing"
+)
+
+func TestValidateListOptionsRejectsIllegalExactCombinations(t *testing.T) {
+	testCases := []struct {
+		name      string
+		opts      storage.ListOptions
+		wantError bool
+	}{
+		{
+			name: "Exact with a specific positive rv is legal",
+			opts: storage.ListOptions{ResourceVersion: "10", ResourceVersionMatch: metav1.ResourceVersionMatchExact},
+		},
+		{
+			name:      "Exact with rv=0 is rejected",
+			opts:      storage.ListOptions{ResourceVersion: "0", ResourceVersionMatch: metav1.ResourceVersionMatchExact},
+			wantError: true,
+		},
+		{
+			name:      "Exact with an empty rv is rejected",
+			opts:      storage.ListOptions{ResourceVersion: "", ResourceVersionMatch: metav1.ResourceVersionMatchExact},
+			wantError: true,
+		},
+		{
+			name: "Exact with a continue token is rejected",
+			opts: storage.ListOptions{
+				ResourceVersion:      "10",
+				ResourceVersionMatch: metav1.ResourceVersionMatchExact,
+				Predicate:            storage.SelectionPredicate{Continue: "some-token"},
+			},
+			wantError: true,
+		},
+		{
+			name: "NotOlderThan with a continue token is legal",
+			opts: storage.ListOptions{
+				ResourceVersion:      "10",
+				ResourceVersionMatch: metav1.ResourceVersionMatchNotOlderThan,
+				Predicate:            storage.SelectionPredicate{Continue: "some-token"},
+			},
+		},
+		{
+			name: "unset match is always legal",
+			opts: storage.ListOptions{ResourceVersion: "0"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := validateListOptions(tc.opts)
+			if tc.wantError && !errors.IsBadRequest(err) {
+				t.Errorf("validateListOptions() = %v, want a BadRequest error", err)
+			}
+			if !tc.wantError && err != nil {
+				t.Errorf("validateListOptions() = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestDecideListStrategy(t *testing.T) {
+	testCases := []struct {
+		name            string
+		resourceVersion uint64
+		opts            storage.ListOptions
+		want            listStrategy
+		wantError       bool
+	}{
+		{
+			name:            "Exact serves from a snapshot",
+			resourceVersion: 10,
+			opts:            storage.ListOptions{ResourceVersion: "10", ResourceVersionMatch: metav1.ResourceVersionMatchExact},
+			want:            listStrategyExactSnapshot,
+		},
+		{
+			name:            "Exact without a usable rv is rejected before a strategy is chosen",
+			resourceVersion: 0,
+			opts:            storage.ListOptions{ResourceVersion: "0", ResourceVersionMatch: metav1.ResourceVersionMatchExact},
+			wantError:       true,
+		},
+		{
+			name:            "NotOlderThan always serves from the live cache",
+			resourceVersion: 10,
+			opts: storage.ListOptions{
+				ResourceVersion:      "10",
+				ResourceVersionMatch: metav1.ResourceVersionMatchNotOlderThan,
+				Predicate:            storage.SelectionPredicate{Limit: 500},
+			},
+			want: listStrategyLatest,
+		},
+		{
+			name:            "unset match, gate-eligible request serves from a snapshot",
+			resourceVersion: 10,
+			opts:            storage.ListOptions{ResourceVersion: "10", Predicate: storage.SelectionPredicate{Limit: 500}},
+			want:            listStrategyExactSnapshot,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			// decideListStrategy's unset-match fallback only takes the
+			// snapshot path when ListFromCacheSnapshot is enabled; pin it
+			// on here so this test doesn't depend on the gate's default.
+			featuregatetesting.SetFeatureGateDuringTest(t, utilfeature.DefaultFeatureGate, features.ListFromCacheSnapshot, true)
+
+			got, err := decideListStrategy(tc.resourceVersion, tc.opts)
+			if tc.wantError {
+				if err == nil {
+					t.Fatalf("decideListStrategy() error = nil, want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decideListStrategy() error = %v, want nil", err)
+			}
+			if got != tc.want {
+				t.Errorf("decideListStrategy() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apiserver/pkg/features"
 	"k8s.io/apiserver/pkg/storage"
 	utilfeature "k8s.io/apiserver/pkg/util/feature"
@@ -37,6 +38,7 @@ func TestListWithFeatureGate(t *testing.T) {
 		name                  string
 		listFromCacheSnapshot bool
 		resourceVersion       string
+		resourceVersionMatch  metav1.ResourceVersionMatch
 		limit                 int64
 		expectListExactRV     bool
 	}{
@@ -75,6 +77,22 @@ func TestListWithFeatureGate(t *testing.T) {
 			limit:                 0,
 			expectListExactRV:     false,
 		},
+		{
+			name:                  "resourceVersionMatch=Exact, rv=\"10\" - should use listExactRV regardless of the feature gate",
+			listFromCacheSnapshot: false,
+			resourceVersion:       "10",
+			resourceVersionMatch:  metav1.ResourceVersionMatchExact,
+			limit:                 500,
+			expectListExactRV:     true,
+		},
+		{
+			name:                  "resourceVersionMatch=NotOlderThan, rv=\"10\" - should use listLatestRV",
+			listFromCacheSnapshot: true,
+			resourceVersion:       "10",
+			resourceVersionMatch:  metav1.ResourceVersionMatchNotOlderThan,
+			limit:                 500,
+			expectListExactRV:     false,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -88,23 +106,22 @@ func TestListWithFeatureGate(t *testing.T) {
 
 			// Create a custom list function for testing
 			listFunc := func(ctx context.Context, resourceVersion uint64, key string, opts storage.ListOptions) (interface{}, error) {
-				// This simulates the condition in watch_cache.go that was fixed
-				if opts.Predicate.Limit > 0 &&
-					len(opts.ResourceVersion) > 0 &&
-					opts.ResourceVersion != "0" &&
-					utilfeature.DefaultFeatureGate.Enabled(features.ListFromCacheSnapshot) &&
-					resourceVersion > 0 {
+				strategy, err := decideListStrategy(resourceVersion, opts)
+				if err != nil {
+					return nil, err
+				}
+				if strategy == listStrategyExactSnapshot {
 					listExactRVCalled = true
 					return nil, errors.NewResourceExpired("expired")
-				} else {
-					listLatestRVCalled = true
-					return "result", nil
 				}
+				listLatestRVCalled = true
+				return "result", nil
 			}
 
 			// Call the list function with the test parameters
 			listOpts := storage.ListOptions{
-				ResourceVersion: tc.resourceVersion,
+				ResourceVersion:      tc.resourceVersion,
+				ResourceVersionMatch: tc.resourceVersionMatch,
 				Predicate: storage.SelectionPredicate{
 					Limit: tc.limit,
 				},
@@ -135,6 +152,7 @@ func TestNegativeResourceVersionList(t *testing.T) {
 		name                  string
 		listFromCacheSnapshot bool
 		resourceVersion       string
+		resourceVersionMatch  metav1.ResourceVersionMatch
 		limit                 int64
 		continueToken         string
 	}{
@@ -157,6 +175,13 @@ func TestNegativeResourceVersionList(t *testing.T) {
 			limit:                 500,
 			continueToken:         "someToken",
 		},
+		{
+			name:                  "resourceVersionMatch=NotOlderThan, negative rv - should use listLatestRV",
+			listFromCacheSnapshot: true,
+			resourceVersion:       "-5",
+			resourceVersionMatch:  metav1.ResourceVersionMatchNotOlderThan,
+			limit:                 500,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -177,23 +202,22 @@ func TestNegativeResourceVersionList(t *testing.T) {
 
 			// Create a custom list function for testing
 			listFunc := func(ctx context.Context, resourceVersion uint64, key string, opts storage.ListOptions) (interface{}, error) {
-				// This simulates the condition in watch_cache.go that was fixed
-				if opts.Predicate.Limit > 0 &&
-					len(opts.ResourceVersion) > 0 &&
-					opts.ResourceVersion != "0" &&
-					utilfeature.DefaultFeatureGate.Enabled(features.ListFromCacheSnapshot) &&
-					resourceVersion > 0 {
+				strategy, err := decideListStrategy(resourceVersion, opts)
+				if err != nil {
+					return nil, err
+				}
+				if strategy == listStrategyExactSnapshot {
 					listExactRVCalled = true
 					return nil, errors.NewResourceExpired("expired")
-				} else {
-					listLatestRVCalled = true
-					return "result", nil
 				}
+				listLatestRVCalled = true
+				return "result", nil
 			}
 
 			// Call the list function with the test parameters
 			listOpts := storage.ListOptions{
-				ResourceVersion: tc.resourceVersion,
+				ResourceVersion:      tc.resourceVersion,
+				ResourceVersionMatch: tc.resourceVersionMatch,
 				Predicate: storage.SelectionPredicate{
 					Limit:    tc.limit,
 					Continue: tc.continueToken,
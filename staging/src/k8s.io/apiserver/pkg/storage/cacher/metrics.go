@@ -0,0 +1,87 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cacher
+
+import (
+	"sync"
+
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+const cacherSubsystem = "storage"
+
+var (
+	listSnapshotHits = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      cacherSubsystem,
+			Name:           "list_snapshot_hits_total",
+			Help:           "Number of listExactRV lookups served by an in-memory ListSnapshotCache snapshot.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"resource"},
+	)
+	listSnapshotMisses = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      cacherSubsystem,
+			Name:           "list_snapshot_misses_total",
+			Help:           "Number of listExactRV lookups for a resourceVersion with no retained snapshot, either because it was never taken or because it has been evicted.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"resource"},
+	)
+	listSnapshotEvictions = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      cacherSubsystem,
+			Name:           "list_snapshot_evictions_total",
+			Help:           "Number of ListSnapshotCache snapshots evicted because their resource's snapshot count exceeded the cache's maximum snapshot count.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"resource"},
+	)
+	delegatorFallbacksTotal = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      cacherSubsystem,
+			Name:           "list_delegator_etcd_fallbacks_total",
+			Help:           "Number of LIST requests CacheDelegator.GetList served from etcd instead of the watch cache, partitioned by resource and the reason for the fallback.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"resource", "reason"},
+	)
+	delegatorResourceVersionLag = metrics.NewHistogramVec(
+		&metrics.HistogramOpts{
+			Subsystem:      cacherSubsystem,
+			Name:           "list_delegator_resource_version_lag",
+			Help:           "How far behind the requested resourceVersion the watch cache was when CacheDelegator.GetList made its serving decision, partitioned by resource.",
+			Buckets:        []float64{0, 1, 2, 5, 10, 20, 50, 100, 200, 500, 1000},
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"resource"},
+	)
+
+	registerMetricsOnce sync.Once
+)
+
+func registerMetrics() {
+	registerMetricsOnce.Do(func() {
+		legacyregistry.MustRegister(listSnapshotHits)
+		legacyregistry.MustRegister(listSnapshotMisses)
+		legacyregistry.MustRegister(listSnapshotEvictions)
+		legacyregistry.MustRegister(delegatorFallbacksTotal)
+		legacyregistry.MustRegister(delegatorResourceVersionLag)
+	})
+}
@@ -0,0 +1,41 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cacher
+
+import "hash/fnv"
+
+// shardIndex deterministically maps a storage key to one of numShards
+// shards. It is the routing primitive a sharded watchCache would use to
+// pick which shard's lock, store and event ring owns a given key, so that
+// the same key always lands on the same shard across Add/Update/Delete and
+// subsequent lookups.
+//
+// NOTE: this repository checkout doesn't carry watch_cache.go (or the rest
+// of the storage.Interface/watch.Event plumbing it depends on), only this
+// package's tests. Wiring shardIndex into newWatchCache, coordinating a
+// shared atomic resourceVersion allocator across shards, and merging
+// per-shard watchCacheIntervals for WaitUntilFreshAndList/
+// getAllEventsSinceLocked all require that missing base implementation, so
+// this change is limited to the hashing primitive itself pending that.
+func shardIndex(key string, numShards int) int {
+	if numShards <= 1 {
+		return 0
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return int(h.Sum32() % uint32(numShards))
+}
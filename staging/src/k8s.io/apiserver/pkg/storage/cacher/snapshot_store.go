@@ -0,0 +1,197 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cacher
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+)
+
+// SnapshotStore persists a consistent point-in-time copy of a watchCache's
+// store at a given resourceVersion, and restores it on a later warm start.
+// Records are opaque, length-prefixed, already-encoded frames: the caller
+// (the watchCache, once it exists in this package) is responsible for
+// encoding/decoding its own storeElements and is expected to discard the
+// snapshot and fall back to a full relist if Load's reported RV is older
+// than etcd's compaction horizon.
+//
+// NOTE: this checkout doesn't carry watch_cache.go, so there's nothing yet
+// to call Save from on a timer/event-count trigger, or to call Load from on
+// startup before opening a catch-up watch at the restored RV. This adds the
+// storage format and the default file-backed implementation so that wiring
+// can be a small, mechanical follow-up once the base watchCache lands.
+type SnapshotStore interface {
+	// Save writes all records yielded by next (which returns io.EOF once
+	// exhausted) as a snapshot at resourceVersion for resource.
+	Save(resourceVersion uint64, resource string, next func() ([]byte, error)) error
+	// Load returns the resourceVersion of the most recent snapshot for
+	// resource and invokes emit once per record it contains, in the order
+	// they were saved. It returns (0, nil) if no snapshot exists.
+	Load(resource string, emit func([]byte) error) (uint64, error)
+}
+
+// manifest is the small fixed-size header written ahead of a snapshot's
+// records: resourceVersion it was taken at, how many records follow, and a
+// checksum of the concatenated record bytes so a truncated or corrupted
+// snapshot file is detected rather than silently under-restored.
+type manifest struct {
+	ResourceVersion uint64
+	RecordCount     uint64
+	Checksum        [sha256.Size]byte
+}
+
+// FileSnapshotStore is the default SnapshotStore, storing one snapshot per
+// resource as a manifest followed by length-prefixed record frames in a
+// single file under dir.
+type FileSnapshotStore struct {
+	dir string
+}
+
+// NewFileSnapshotStore returns a FileSnapshotStore rooted at dir, which must
+// already exist.
+func NewFileSnapshotStore(dir string) *FileSnapshotStore {
+	return &FileSnapshotStore{dir: dir}
+}
+
+func (s *FileSnapshotStore) path(resource string) string {
+	return s.dir + "/" + resource + ".snapshot"
+}
+
+func (s *FileSnapshotStore) Save(resourceVersion uint64, resource string, next func() ([]byte, error)) error {
+	tmpPath := s.path(resource) + ".tmp"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return fmt.Errorf("cacher: creating snapshot file: %w", err)
+	}
+	defer f.Close()
+
+	var records [][]byte
+	sum := sha256.New()
+	for {
+		record, err := next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("cacher: reading snapshot record: %w", err)
+		}
+		sum.Write(record)
+		records = append(records, record)
+	}
+
+	m := manifest{ResourceVersion: resourceVersion, RecordCount: uint64(len(records))}
+	copy(m.Checksum[:], sum.Sum(nil))
+	if err := writeManifest(f, m); err != nil {
+		return err
+	}
+	for _, record := range records {
+		if err := writeFrame(f, record); err != nil {
+			return err
+		}
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("cacher: closing snapshot file: %w", err)
+	}
+	return os.Rename(tmpPath, s.path(resource))
+}
+
+func (s *FileSnapshotStore) Load(resource string, emit func([]byte) error) (uint64, error) {
+	f, err := os.Open(s.path(resource))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("cacher: opening snapshot file: %w", err)
+	}
+	defer f.Close()
+
+	m, err := readManifest(f)
+	if err != nil {
+		return 0, err
+	}
+
+	sum := sha256.New()
+	for i := uint64(0); i < m.RecordCount; i++ {
+		record, err := readFrame(f)
+		if err != nil {
+			return 0, fmt.Errorf("cacher: reading snapshot record %d/%d: %w", i+1, m.RecordCount, err)
+		}
+		sum.Write(record)
+		if err := emit(record); err != nil {
+			return 0, err
+		}
+	}
+	if got := sum.Sum(nil); hex.EncodeToString(got) != hex.EncodeToString(m.Checksum[:]) {
+		return 0, fmt.Errorf("cacher: snapshot for %q failed checksum validation, discarding", resource)
+	}
+	return m.ResourceVersion, nil
+}
+
+func writeManifest(w io.Writer, m manifest) error {
+	var header [8 + 8]byte
+	binary.BigEndian.PutUint64(header[0:8], m.ResourceVersion)
+	binary.BigEndian.PutUint64(header[8:16], m.RecordCount)
+	if _, err := w.Write(header[:]); err != nil {
+		return fmt.Errorf("cacher: writing snapshot manifest: %w", err)
+	}
+	if _, err := w.Write(m.Checksum[:]); err != nil {
+		return fmt.Errorf("cacher: writing snapshot checksum: %w", err)
+	}
+	return nil
+}
+
+func readManifest(r io.Reader) (manifest, error) {
+	var m manifest
+	var header [8 + 8]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return m, fmt.Errorf("cacher: reading snapshot manifest: %w", err)
+	}
+	m.ResourceVersion = binary.BigEndian.Uint64(header[0:8])
+	m.RecordCount = binary.BigEndian.Uint64(header[8:16])
+	if _, err := io.ReadFull(r, m.Checksum[:]); err != nil {
+		return m, fmt.Errorf("cacher: reading snapshot checksum: %w", err)
+	}
+	return m, nil
+}
+
+func writeFrame(w io.Writer, record []byte) error {
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(record)))
+	if _, err := w.Write(length[:]); err != nil {
+		return fmt.Errorf("cacher: writing snapshot record length: %w", err)
+	}
+	if _, err := w.Write(record); err != nil {
+		return fmt.Errorf("cacher: writing snapshot record: %w", err)
+	}
+	return nil
+}
+
+func readFrame(r io.Reader) ([]byte, error) {
+	var length [4]byte
+	if _, err := io.ReadFull(r, length[:]); err != nil {
+		return nil, err
+	}
+	record := make([]byte, binary.BigEndian.Uint32(length[:]))
+	if _, err := io.ReadFull(r, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
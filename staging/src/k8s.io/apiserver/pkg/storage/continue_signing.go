@@ -0,0 +1,233 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrInvalidContinueSignature is returned when a continue token's signature
+// doesn't verify against any currently trusted key, or carries a kid that
+// isn't known at all. It's distinct from the unsigned-token errors in
+// continue.go (ErrInvalidStartRV etc.) so callers can tell "malformed" apart
+// from "tampered".
+var ErrInvalidContinueSignature = errors.New("continue token has an invalid or missing signature")
+
+// ContinueSigningKeyProvider supplies the rotating symmetric key set used to
+// sign and verify continue tokens, analogous to the service-account signing
+// key set loaded from --service-account-key-file: one current key used to
+// sign new tokens, plus zero or more retired keys still trusted for
+// verifying tokens signed before the last rotation.
+type ContinueSigningKeyProvider interface {
+	// CurrentKey returns the key id and key material used to sign new
+	// continue tokens.
+	CurrentKey() (kid string, key []byte)
+	// TrustedKey returns the key material for kid, and false if kid is
+	// unknown (expired out of rotation, or never existed).
+	TrustedKey(kid string) (key []byte, ok bool)
+}
+
+// continuePayload is the subset of continueToken (see continue.go) covered
+// by the HMAC: the fields a forged token would need to alter to resume a
+// LIST at an attacker-chosen key.
+//
+// NOTE: this checkout's continue.go - which would define the real
+// continueToken, DecodeContinue and PrepareContinueToken, plus the
+// SelectionPredicate/ListOptions/fields.Fields types PrepareContinueToken
+// takes - isn't present, only continue_test.go. So this adds the signing
+// primitive as a standalone layer with its own minimal payload type instead
+// of literally extending continueToken with a Signature field. Wiring
+// SignedContinueCodec into PrepareContinueToken/DecodeContinue (embedding
+// {kid, mac} alongside StartKey/APIVersion/ResourceVersion, and threading
+// the feature gate that picks DecodeWithFallback's allowUnsigned argument)
+// is a mechanical follow-up once that file exists.
+type continuePayload struct {
+	APIVersion      string `json:"apiVersion"`
+	ResourceVersion int64  `json:"rv"`
+	StartKey        string `json:"start"`
+}
+
+// signedContinueToken is the wire format SignedContinueCodec encodes:
+// continuePayload plus the kid/mac pair proving it was issued by this
+// cluster's apiserver rather than forged by a client.
+type signedContinueToken struct {
+	continuePayload
+	KeyID     string `json:"kid,omitempty"`
+	Signature []byte `json:"mac,omitempty"`
+}
+
+// SignedContinueCodec signs and verifies continue tokens with HMAC-SHA256,
+// so a client can't resume a LIST at an arbitrary etcd key by hand-crafting
+// the base64-encoded JSON blob DecodeContinue otherwise accepts unchecked.
+type SignedContinueCodec struct {
+	keys ContinueSigningKeyProvider
+}
+
+// NewSignedContinueCodec returns a SignedContinueCodec that signs with, and
+// verifies against, the keys keyProvider supplies.
+func NewSignedContinueCodec(keyProvider ContinueSigningKeyProvider) *SignedContinueCodec {
+	return &SignedContinueCodec{keys: keyProvider}
+}
+
+// Encode signs {apiVersion, resourceVersion, startKey} with the current key
+// and returns the base64-encoded continue token.
+func (c *SignedContinueCodec) Encode(apiVersion string, resourceVersion int64, startKey string) (string, error) {
+	kid, key := c.keys.CurrentKey()
+	payload := continuePayload{APIVersion: apiVersion, ResourceVersion: resourceVersion, StartKey: startKey}
+
+	out, err := json.Marshal(signedContinueToken{
+		continuePayload: payload,
+		KeyID:           kid,
+		Signature:       computeContinueMAC(key, kid, payload),
+	})
+	if err != nil {
+		return "", fmt.Errorf("encoding signed continue token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(out), nil
+}
+
+// Decode verifies and decodes a token produced by Encode, returning
+// ErrInvalidContinueSignature if the mac doesn't match the key named by kid,
+// or kid isn't trusted (expired out of rotation or unknown).
+func (c *SignedContinueCodec) Decode(continueValue string) (apiVersion string, resourceVersion int64, startKey string, err error) {
+	token, err := decodeSignedContinueToken(continueValue)
+	if err != nil {
+		return "", 0, "", err
+	}
+
+	key, ok := c.keys.TrustedKey(token.KeyID)
+	if !ok {
+		return "", 0, "", fmt.Errorf("%w: key id %q is not trusted", ErrInvalidContinueSignature, token.KeyID)
+	}
+	if !hmac.Equal(computeContinueMAC(key, token.KeyID, token.continuePayload), token.Signature) {
+		return "", 0, "", ErrInvalidContinueSignature
+	}
+	return token.APIVersion, token.ResourceVersion, token.StartKey, nil
+}
+
+// DecodeWithFallback behaves like Decode, but if the token carries no
+// signature at all it's accepted as a legacy unsigned token when
+// allowUnsigned is true - the rollout fallback so existing clients, and
+// tokens issued before signing was enabled, keep working while the feature
+// gate is only partially rolled out across a cluster's apiservers.
+func (c *SignedContinueCodec) DecodeWithFallback(continueValue string, allowUnsigned bool) (apiVersion string, resourceVersion int64, startKey string, err error) {
+	token, err := decodeSignedContinueToken(continueValue)
+	if err != nil {
+		return "", 0, "", err
+	}
+
+	if token.KeyID == "" {
+		if !allowUnsigned {
+			return "", 0, "", fmt.Errorf("%w: unsigned continue tokens are not accepted", ErrInvalidContinueSignature)
+		}
+		return token.APIVersion, token.ResourceVersion, token.StartKey, nil
+	}
+
+	key, ok := c.keys.TrustedKey(token.KeyID)
+	if !ok {
+		return "", 0, "", fmt.Errorf("%w: key id %q is not trusted", ErrInvalidContinueSignature, token.KeyID)
+	}
+	if !hmac.Equal(computeContinueMAC(key, token.KeyID, token.continuePayload), token.Signature) {
+		return "", 0, "", ErrInvalidContinueSignature
+	}
+	return token.APIVersion, token.ResourceVersion, token.StartKey, nil
+}
+
+func decodeSignedContinueToken(continueValue string) (signedContinueToken, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(continueValue)
+	if err != nil {
+		return signedContinueToken{}, errors.New("illegal base64 continue token")
+	}
+	var token signedContinueToken
+	if err := json.Unmarshal(raw, &token); err != nil {
+		return signedContinueToken{}, fmt.Errorf("decoding continue token: %w", err)
+	}
+	return token, nil
+}
+
+// computeContinueMAC returns HMAC-SHA256(key, kid || apiVersion || rv ||
+// startKey), binding the signature to the key that produced it as well as
+// the payload so a token signed under one kid can't be replayed with
+// another kid's mac.
+func computeContinueMAC(key []byte, kid string, payload continuePayload) []byte {
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "%s|%s|%d|%s", kid, payload.APIVersion, payload.ResourceVersion, payload.StartKey)
+	return mac.Sum(nil)
+}
+
+// StaticContinueSigningKeys is a ContinueSigningKeyProvider backed by a
+// fixed, in-memory key set: one current key plus any retired keys still
+// kept around to verify tokens issued before the last rotation. No
+// command-line flag loads it; the keys are the arguments to
+// NewStaticContinueSigningKeys.
+type StaticContinueSigningKeys struct {
+	mu         sync.RWMutex
+	currentKid string
+	keys       map[string][]byte
+}
+
+// NewStaticContinueSigningKeys returns a StaticContinueSigningKeys with
+// currentKid as the signing key and keys as the full trusted set, which
+// must include currentKid.
+func NewStaticContinueSigningKeys(currentKid string, keys map[string][]byte) (*StaticContinueSigningKeys, error) {
+	if _, ok := keys[currentKid]; !ok {
+		return nil, fmt.Errorf("continue token signing key %q not found in key set", currentKid)
+	}
+	copied := make(map[string][]byte, len(keys))
+	for kid, key := range keys {
+		copied[kid] = key
+	}
+	return &StaticContinueSigningKeys{currentKid: currentKid, keys: copied}, nil
+}
+
+func (s *StaticContinueSigningKeys) CurrentKey() (string, []byte) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.currentKid, s.keys[s.currentKid]
+}
+
+func (s *StaticContinueSigningKeys) TrustedKey(kid string) ([]byte, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+// Rotate installs newKid as the current signing key, adding it to the
+// trusted set. Previously current keys remain trusted for verification
+// until a caller explicitly Expires them.
+func (s *StaticContinueSigningKeys) Rotate(newKid string, newKey []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys[newKid] = newKey
+	s.currentKid = newKid
+}
+
+// Expire removes kid from the trusted set, so tokens signed under it are
+// rejected with ErrInvalidContinueSignature instead of being accepted
+// indefinitely after it should have aged out of rotation.
+func (s *StaticContinueSigningKeys) Expire(kid string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.keys, kid)
+}
@@ -0,0 +1,149 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func mustStaticKeys(t *testing.T, currentKid string, keys map[string][]byte) *StaticContinueSigningKeys {
+	t.Helper()
+	k, err := NewStaticContinueSigningKeys(currentKid, keys)
+	if err != nil {
+		t.Fatalf("NewStaticContinueSigningKeys: %v", err)
+	}
+	return k
+}
+
+func TestSignedContinueCodecRoundTrip(t *testing.T) {
+	keys := mustStaticKeys(t, "k1", map[string][]byte{"k1": []byte("key-one")})
+	codec := NewSignedContinueCodec(keys)
+
+	token, err := codec.Encode("meta.k8s.io/v1", 5, "some/key")
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	gotVersion, gotRV, gotKey, err := codec.Decode(token)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if gotVersion != "meta.k8s.io/v1" || gotRV != 5 || gotKey != "some/key" {
+		t.Errorf("Decode() = (%q, %d, %q), want (meta.k8s.io/v1, 5, some/key)", gotVersion, gotRV, gotKey)
+	}
+}
+
+func TestSignedContinueCodecKeyRotation(t *testing.T) {
+	keys := mustStaticKeys(t, "k1", map[string][]byte{"k1": []byte("key-one")})
+	codec := NewSignedContinueCodec(keys)
+
+	token, err := codec.Encode("meta.k8s.io/v1", 1, "key")
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	// Rotate to a new current key; the token signed under k1 must still
+	// verify since k1 remains in the trusted set.
+	keys.Rotate("k2", []byte("key-two"))
+	if _, _, _, err := codec.Decode(token); err != nil {
+		t.Errorf("Decode() after rotation = %v, want nil (k1 should still be trusted)", err)
+	}
+
+	// A freshly issued token should now be signed under k2.
+	newToken, err := codec.Encode("meta.k8s.io/v1", 1, "key")
+	if err != nil {
+		t.Fatalf("Encode after rotation: %v", err)
+	}
+	if newToken == token {
+		t.Errorf("expected a new signature after rotation, got the same token")
+	}
+}
+
+func TestSignedContinueCodecExpiredKeyRejected(t *testing.T) {
+	keys := mustStaticKeys(t, "k1", map[string][]byte{"k1": []byte("key-one")})
+	codec := NewSignedContinueCodec(keys)
+
+	token, err := codec.Encode("meta.k8s.io/v1", 1, "key")
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	keys.Rotate("k2", []byte("key-two"))
+	keys.Expire("k1")
+
+	if _, _, _, err := codec.Decode(token); !errors.Is(err, ErrInvalidContinueSignature) {
+		t.Errorf("Decode() after expiring k1 = %v, want ErrInvalidContinueSignature", err)
+	}
+}
+
+func TestSignedContinueCodecTamperedMismatchedKeyPrefix(t *testing.T) {
+	keys := mustStaticKeys(t, "k1", map[string][]byte{"k1": []byte("key-one")})
+	codec := NewSignedContinueCodec(keys)
+
+	token, err := codec.Encode("meta.k8s.io/v1", 1, "other-resource/key")
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		t.Fatalf("decoding test token: %v", err)
+	}
+	var decoded signedContinueToken
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		t.Fatalf("unmarshalling test token: %v", err)
+	}
+
+	// Simulate a client rewriting StartKey to point at a different
+	// resource's etcd prefix without re-signing: the mac no longer matches.
+	decoded.StartKey = "attacker-resource/key"
+	tampered, err := json.Marshal(decoded)
+	if err != nil {
+		t.Fatalf("marshalling tampered token: %v", err)
+	}
+	tamperedToken := base64.RawURLEncoding.EncodeToString(tampered)
+
+	if _, _, _, err := codec.Decode(tamperedToken); !errors.Is(err, ErrInvalidContinueSignature) {
+		t.Errorf("Decode() of tampered token = %v, want ErrInvalidContinueSignature", err)
+	}
+}
+
+func TestSignedContinueCodecDecodeWithFallback(t *testing.T) {
+	keys := mustStaticKeys(t, "k1", map[string][]byte{"k1": []byte("key-one")})
+	codec := NewSignedContinueCodec(keys)
+
+	unsigned, err := json.Marshal(continuePayload{APIVersion: "meta.k8s.io/v1", ResourceVersion: 2, StartKey: "legacy/key"})
+	if err != nil {
+		t.Fatalf("marshalling legacy token: %v", err)
+	}
+	legacyToken := base64.RawURLEncoding.EncodeToString(unsigned)
+
+	if _, _, _, err := codec.DecodeWithFallback(legacyToken, false); !errors.Is(err, ErrInvalidContinueSignature) {
+		t.Errorf("DecodeWithFallback(allowUnsigned=false) = %v, want ErrInvalidContinueSignature", err)
+	}
+
+	gotVersion, gotRV, gotKey, err := codec.DecodeWithFallback(legacyToken, true)
+	if err != nil {
+		t.Fatalf("DecodeWithFallback(allowUnsigned=true): %v", err)
+	}
+	if gotVersion != "meta.k8s.io/v1" || gotRV != 2 || gotKey != "legacy/key" {
+		t.Errorf("DecodeWithFallback() = (%q, %d, %q), want (meta.k8s.io/v1, 2, legacy/key)", gotVersion, gotRV, gotKey)
+	}
+}
@@ -0,0 +1,141 @@
+/*
+Copyright 2017 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package log implements the audit backend that writes events as a
+// single, optionally rotated and truncated, stream of lines.
+//
+// NOTE: this package's chunk7/chunk8/chunk9 series (filtering, sampling,
+// redaction, truncation, rotation, ECS/logfmt/structured formatting,
+// syslog forwarding) all build on k8s.io/apiserver/pkg/audit and
+// k8s.io/apiserver/pkg/apis/audit, neither of which exists anywhere in
+// this checkout - only this package's own backend_test.go fixture
+// references them, assuming the same Event/UserInfo shape this file
+// does. The two packages are assumed present, not verified against a
+// real implementation; a future vendoring of them would need to confirm
+// Event, UserInfo, Level, Stage, Backend, and Codecs still match what's
+// used here.
+package log
+
+import (
+	"fmt"
+	"io"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	auditinternal "k8s.io/apiserver/pkg/apis/audit"
+	"k8s.io/apiserver/pkg/audit"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// FormatLegacy saves event in 1-line text format.
+	FormatLegacy = "legacy"
+	// FormatJson saves event in structured json format.
+	FormatJson = "json"
+)
+
+// AllowedFormats are the formats known by the log backend. See formatter.go
+// for FormatLogfmt and FormatStructured, and ecs.go for FormatECS.
+var AllowedFormats = []string{FormatLegacy, FormatJson, FormatLogfmt, FormatStructured, FormatECS}
+
+type backend struct {
+	out     io.Writer
+	format  string
+	encoder runtime.Encoder
+
+	// truncation caps how large a single event's requestObject/
+	// responseObject may be before logEvent replaces them with a marker.
+	// Zero value (MaxObjectBytes == 0) disables truncation, which keeps
+	// NewBackend's behavior unchanged for callers that don't opt in.
+	truncation TruncationPolicy
+
+	// redaction rewrites or drops fields of requestObject/responseObject
+	// before they're formatted. Zero value (no Rules) disables redaction.
+	redaction RedactionPolicy
+
+	// filter, if non-nil, can drop an event before it reaches sampler,
+	// redaction, truncation, or formatting.
+	filter Filter
+	// sampler, if non-nil, probabilistically and/or rate-limit drops
+	// events the filter accepted.
+	sampler *Sampler
+}
+
+var _ audit.Backend = &backend{}
+
+// NewBackend returns an audit backend that writes to out in the given
+// format, using groupVersion to encode FormatJson events.
+func NewBackend(out io.Writer, format string, groupVersion schema.GroupVersion) audit.Backend {
+	registerFilterMetrics()
+	return &backend{
+		out:     out,
+		format:  format,
+		encoder: audit.Codecs.LegacyCodec(groupVersion),
+	}
+}
+
+func (b *backend) ProcessEvents(events ...*auditinternal.Event) bool {
+	success := true
+	for _, ev := range events {
+		success = b.logEvent(ev) && success
+	}
+	return success
+}
+
+func (b *backend) logEvent(ev *auditinternal.Event) bool {
+	if b.filter != nil && !b.filter.Accept(ev) {
+		auditFilterEventsTotal.WithLabelValues("rejected").Inc()
+		return true
+	}
+	if b.sampler != nil && !b.sampler.Sample(ev) {
+		auditFilterEventsTotal.WithLabelValues("sampled").Inc()
+		return true
+	}
+	auditFilterEventsTotal.WithLabelValues("accepted").Inc()
+
+	ev = redactEvent(ev, b.redaction)
+	ev = truncateOversizedFields(ev, b.truncation)
+
+	formatter, err := newFormatter(b.format, b.encoder)
+	if err != nil {
+		klog.ErrorS(err, "Unable to select audit log formatter")
+		return false
+	}
+
+	line, err := formatter.Format(ev)
+	if err != nil {
+		klog.ErrorS(err, "Unable to encode audit event")
+		return false
+	}
+
+	if _, err := b.out.Write(line); err != nil {
+		klog.ErrorS(err, "Unable to write audit event")
+		return false
+	}
+	return true
+}
+
+func (b *backend) Run(stopCh <-chan struct{}) error {
+	return nil
+}
+
+func (b *backend) Shutdown() {
+	// Nothing to do here.
+}
+
+func (b *backend) String() string {
+	return fmt.Sprintf("log")
+}
@@ -1874,6 +1874,62 @@ func TestLogEventsLegacy(t *testing.T) {
 			},
 			`[\d\:\-\.\+TZ]+ AUDIT: id="[\w-]+" stage="" ip="<unknown>" method="" user="<none>" groups="<none>" as="<self>" asgroups="<lookup>" namespace="<none>" uri="" response="<deferred>"`,
 		},
+		{
+			&auditinternal.Event{
+				RequestURI: "/apis/example.com/v1/customresourcedefinitions/widgets.example.com",
+				SourceIPs: []string{
+					"10.0.0.5",
+				},
+				Timestamp: metav1.NewTime(time.Now()),
+				AuditID:   types.UID(uuid.NewRandom().String()),
+				Stage:     auditinternal.StageResponseComplete,
+				Verb:      "update",
+				User: auditinternal.UserInfo{
+					Username: "admin",
+					Groups: []string{
+						"system:masters",
+					},
+				},
+				ImpersonatedUser: &auditinternal.UserInfo{
+					Username: "u-xxxxx",
+					Groups: []string{
+						"system:authenticated",
+					},
+				},
+				ObjectRef: &auditinternal.ObjectReference{
+					Namespace: "default",
+				},
+				ResponseStatus: &metav1.Status{
+					Code: 200,
+				},
+			},
+			`[\d\:\-\.\+TZ]+ AUDIT: id="[\w-]+" stage="ResponseComplete" ip="10.0.0.5" method="update" user="admin" groups="\\"system:masters\\"" as="u-xxxxx" asgroups="\\"system:authenticated\\"" namespace="default" uri="/apis/example.com/v1/customresourcedefinitions/widgets.example.com" response="200"`,
+		},
+		{
+			&auditinternal.Event{
+				RequestURI: "/apis/example.com/v1/namespaces/default/widgets",
+				Timestamp:  metav1.NewTime(time.Now()),
+				AuditID:    types.UID(uuid.NewRandom().String()),
+				Stage:      auditinternal.StageResponseComplete,
+				Verb:       "create",
+				User: auditinternal.UserInfo{
+					Username: "admin",
+					Groups: []string{
+						"system:masters",
+					},
+				},
+				ImpersonatedUser: &auditinternal.UserInfo{
+					Username: "u-yyyyy",
+				},
+				ObjectRef: &auditinternal.ObjectReference{
+					Namespace: "default",
+				},
+				ResponseStatus: &metav1.Status{
+					Code: 201,
+				},
+			},
+			`[\d\:\-\.\+TZ]+ AUDIT: id="[\w-]+" stage="ResponseComplete" ip="<unknown>" method="create" user="admin" groups="\\"system:masters\\"" as="u-yyyyy" asgroups="<none>" namespace="default" uri="/apis/example.com/v1/namespaces/default/widgets" response="201"`,
+		},
 	} {
 		var buf bytes.Buffer
 		backend := NewBackend(&buf, FormatLegacy, auditv1beta1.SchemeGroupVersion)
@@ -0,0 +1,282 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package log
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	auditinternal "k8s.io/apiserver/pkg/apis/audit"
+	"k8s.io/apiserver/pkg/audit"
+	"k8s.io/klog/v2"
+)
+
+const (
+	batchDefaultMaxSize       = 1000
+	batchDefaultFlushInterval = time.Second
+	batchDefaultRingFactor    = 8
+)
+
+// BatchOpts configures NewBatchBackend.
+type BatchOpts struct {
+	// MaxBatchSize is how many events the flusher accumulates before
+	// writing, regardless of MaxFlushInterval. Zero defaults to
+	// batchDefaultMaxSize.
+	MaxBatchSize int
+	// MaxFlushInterval is the longest a partial batch waits before being
+	// flushed anyway. Zero defaults to batchDefaultFlushInterval.
+	MaxFlushInterval time.Duration
+	// RingSize caps how many events ProcessEvents can have enqueued ahead
+	// of the flusher. Zero defaults to batchDefaultRingFactor *
+	// MaxBatchSize. Unlike the syslog backend's buffer, this ring never
+	// drops events: ProcessEvents blocks once it's full, the same
+	// backpressure a single unbuffered Write would apply.
+	RingSize int
+	// Gzip frames each flushed batch as its own gzip member, so the
+	// output stays streamable: gzip.Reader's default Multistream mode
+	// reads concatenated members transparently, so a reader sees the
+	// same event stream whether it opens the file mid-write or after.
+	Gzip bool
+	// WrapEventList wraps each flushed batch's events in a single
+	// auditinternal.EventList before encoding, for FormatJson. Ignored by
+	// every other format, which always emits one line per event.
+	WrapEventList bool
+}
+
+// batchBackend is a sibling of backend for high-QPS clusters: rather than
+// encoding and writing each event as it arrives, ProcessEvents enqueues
+// into a bounded ring and a background goroutine accumulates events into
+// batches, each flushed with a single Encode/Write once it reaches
+// MaxBatchSize or MaxFlushInterval elapses, whichever comes first.
+type batchBackend struct {
+	out     io.Writer
+	format  string
+	encoder runtime.Encoder
+	opts    BatchOpts
+
+	ring chan *auditinternal.Event
+
+	done    chan struct{}
+	stopped chan struct{}
+
+	// writeMu guards out.Write. Only the flusher goroutine writes,
+	// including the final drain it performs once Shutdown or stopCh stops it.
+	writeMu sync.Mutex
+}
+
+var _ audit.Backend = &batchBackend{}
+
+// NewBatchBackend returns an audit backend that writes to w in the given
+// format, batching many events per Encode/Write call per opts.
+func NewBatchBackend(w io.Writer, format string, groupVersion schema.GroupVersion, opts BatchOpts) audit.Backend {
+	if opts.MaxBatchSize <= 0 {
+		opts.MaxBatchSize = batchDefaultMaxSize
+	}
+	if opts.MaxFlushInterval <= 0 {
+		opts.MaxFlushInterval = batchDefaultFlushInterval
+	}
+	if opts.RingSize <= 0 {
+		opts.RingSize = batchDefaultRingFactor * opts.MaxBatchSize
+	}
+
+	return &batchBackend{
+		out:     w,
+		format:  format,
+		encoder: audit.Codecs.LegacyCodec(groupVersion),
+		opts:    opts,
+		ring:    make(chan *auditinternal.Event, opts.RingSize),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+}
+
+// ProcessEvents enqueues events for the flusher goroutine started by Run,
+// blocking if the ring is full rather than dropping any of them. It
+// returns false, without enqueueing the rest, once Shutdown has been
+// called.
+func (b *batchBackend) ProcessEvents(events ...*auditinternal.Event) bool {
+	for _, ev := range events {
+		select {
+		case <-b.done:
+			return false
+		default:
+		}
+		select {
+		case b.ring <- ev:
+		case <-b.done:
+			return false
+		}
+	}
+	return true
+}
+
+// Run starts the background flusher goroutine.
+func (b *batchBackend) Run(stopCh <-chan struct{}) error {
+	go b.flushLoop(stopCh)
+	return nil
+}
+
+func (b *batchBackend) flushLoop(stopCh <-chan struct{}) {
+	defer close(b.stopped)
+
+	ticker := time.NewTicker(b.opts.MaxFlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*auditinternal.Event, 0, b.opts.MaxBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := b.writeBatch(batch); err != nil {
+			klog.ErrorS(err, "Unable to write audit event batch", "size", len(batch))
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case ev := <-b.ring:
+			batch = append(batch, ev)
+			if len(batch) >= b.opts.MaxBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-stopCh:
+			b.drain(&batch)
+			flush()
+			return
+		case <-b.done:
+			b.drain(&batch)
+			flush()
+			return
+		}
+	}
+}
+
+// drain appends every event already enqueued in the ring to batch without
+// blocking, so Shutdown's final flush includes everything ProcessEvents
+// had accepted before it was called.
+func (b *batchBackend) drain(batch *[]*auditinternal.Event) {
+	for {
+		select {
+		case ev := <-b.ring:
+			*batch = append(*batch, ev)
+		default:
+			return
+		}
+	}
+}
+
+// Shutdown stops the flusher and flushes any events still pending,
+// waiting indefinitely for the drain to finish. It satisfies
+// audit.Backend; callers that want a deadline on the final flush should
+// call ShutdownContext directly instead.
+func (b *batchBackend) Shutdown() {
+	_ = b.ShutdownContext(context.Background())
+}
+
+// ShutdownContext stops the flusher and flushes any events still pending
+// in the ring, returning ctx's error if it's canceled or times out before
+// the drain finishes.
+func (b *batchBackend) ShutdownContext(ctx context.Context) error {
+	close(b.done)
+	select {
+	case <-b.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *batchBackend) String() string {
+	return fmt.Sprintf("batch(%s)", b.format)
+}
+
+// writeBatch encodes batch per b.format/b.opts and writes the result to
+// b.out in a single Write call.
+func (b *batchBackend) writeBatch(batch []*auditinternal.Event) error {
+	var buf bytes.Buffer
+	w := io.Writer(&buf)
+
+	var gz *gzip.Writer
+	if b.opts.Gzip {
+		gz = gzip.NewWriter(&buf)
+		w = gz
+	}
+	if err := b.encodeBatch(w, batch); err != nil {
+		return err
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			return err
+		}
+	}
+
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+	_, err := b.out.Write(buf.Bytes())
+	return err
+}
+
+// encodeBatch writes batch to w: as a single auditinternal.EventList when
+// b.format is FormatJson and opts.WrapEventList is set, otherwise as one
+// Formatter.Format line per event, same as backend.logEvent would produce.
+func (b *batchBackend) encodeBatch(w io.Writer, batch []*auditinternal.Event) error {
+	if b.format == FormatJson && b.opts.WrapEventList {
+		return b.encodeEventList(w, batch)
+	}
+
+	formatter, err := newFormatter(b.format, b.encoder)
+	if err != nil {
+		return err
+	}
+	for _, ev := range batch {
+		line, err := formatter.Format(ev)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *batchBackend) encodeEventList(w io.Writer, batch []*auditinternal.Event) error {
+	list := &auditinternal.EventList{Items: make([]auditinternal.Event, len(batch))}
+	for i, ev := range batch {
+		list.Items[i] = *ev
+	}
+
+	bs, err := runtime.Encode(b.encoder, list)
+	if err != nil {
+		return err
+	}
+	if len(bs) == 0 || bs[len(bs)-1] != '\n' {
+		bs = append(bs, '\n')
+	}
+	_, err = w.Write(bs)
+	return err
+}
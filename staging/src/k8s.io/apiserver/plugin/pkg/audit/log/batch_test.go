@@ -0,0 +1,203 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package log
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	auditinternal "k8s.io/apiserver/pkg/apis/audit"
+	auditv1beta1 "k8s.io/apiserver/pkg/apis/audit/v1beta1"
+)
+
+// syncBuffer is an io.Writer safe for a flusher goroutine to write to
+// while the test concurrently inspects its contents.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (s *syncBuffer) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.Write(p)
+}
+
+func (s *syncBuffer) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.Len()
+}
+
+func (s *syncBuffer) Bytes() []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]byte, s.buf.Len())
+	copy(out, s.buf.Bytes())
+	return out
+}
+
+// waitForBufferGrowth blocks until buf grows past minLen, failing the test
+// if it doesn't within a few seconds.
+func waitForBufferGrowth(t *testing.T, buf *syncBuffer, minLen int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for buf.Len() <= minLen {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for a flush past %d bytes, have %d", minLen, buf.Len())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestBatchBackendFlushesOnMaxBatchSize(t *testing.T) {
+	var out syncBuffer
+	backend := NewBatchBackend(&out, FormatLegacy, auditv1beta1.SchemeGroupVersion, BatchOpts{
+		MaxBatchSize:     3,
+		MaxFlushInterval: time.Hour,
+	}).(*batchBackend)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	if err := backend.Run(stopCh); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	defer backend.Shutdown()
+
+	for _, id := range []types.UID{"a", "b", "c"} {
+		backend.ProcessEvents(&auditinternal.Event{AuditID: id})
+	}
+
+	waitForBufferGrowth(t, &out, 0)
+	body := string(out.Bytes())
+	if got := strings.Count(body, "\n"); got != 3 {
+		t.Errorf("got %d lines after the size-triggered flush, want 3: %q", got, body)
+	}
+}
+
+func TestBatchBackendFlushesOnInterval(t *testing.T) {
+	var out syncBuffer
+	backend := NewBatchBackend(&out, FormatLegacy, auditv1beta1.SchemeGroupVersion, BatchOpts{
+		MaxBatchSize:     100,
+		MaxFlushInterval: 20 * time.Millisecond,
+	}).(*batchBackend)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	if err := backend.Run(stopCh); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	defer backend.Shutdown()
+
+	backend.ProcessEvents(&auditinternal.Event{AuditID: "only-one"})
+
+	waitForBufferGrowth(t, &out, 0)
+	if got := strings.Count(string(out.Bytes()), "\n"); got != 1 {
+		t.Errorf("got %d lines after the interval-triggered flush, want 1", got)
+	}
+}
+
+func TestBatchBackendGzipFramesConcatenateAndDecompress(t *testing.T) {
+	var out syncBuffer
+	backend := NewBatchBackend(&out, FormatLegacy, auditv1beta1.SchemeGroupVersion, BatchOpts{
+		MaxBatchSize:     2,
+		MaxFlushInterval: time.Hour,
+		Gzip:             true,
+	}).(*batchBackend)
+
+	stopCh := make(chan struct{})
+	if err := backend.Run(stopCh); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	backend.ProcessEvents(&auditinternal.Event{AuditID: "a"}, &auditinternal.Event{AuditID: "b"})
+	waitForBufferGrowth(t, &out, 0)
+	firstLen := out.Len()
+
+	backend.ProcessEvents(&auditinternal.Event{AuditID: "c"}, &auditinternal.Event{AuditID: "d"})
+	waitForBufferGrowth(t, &out, firstLen)
+
+	close(stopCh)
+	backend.Shutdown()
+
+	zr, err := gzip.NewReader(bytes.NewReader(out.Bytes()))
+	if err != nil {
+		t.Fatalf("gzip.NewReader() error = %v", err)
+	}
+	body, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("reading concatenated gzip members: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimRight(string(body), "\n"), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("decompressed %d lines, want 4: %q", len(lines), body)
+	}
+	for i, id := range []string{"a", "b", "c", "d"} {
+		if !strings.Contains(lines[i], `id="`+id+`"`) {
+			t.Errorf("line %d = %q, want audit id %q", i, lines[i], id)
+		}
+	}
+}
+
+func benchmarkEvent() *auditinternal.Event {
+	return &auditinternal.Event{
+		AuditID: types.UID("bench"),
+		Verb:    "list",
+		ResponseObject: &runtime.Unknown{
+			Raw:         []byte(payload),
+			ContentType: runtime.ContentTypeJSON,
+		},
+	}
+}
+
+func BenchmarkPerEventBackend(b *testing.B) {
+	backend := NewBackend(io.Discard, FormatJson, auditv1beta1.SchemeGroupVersion)
+	ev := benchmarkEvent()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		backend.ProcessEvents(ev)
+	}
+}
+
+func BenchmarkBatchBackend(b *testing.B) {
+	backend := NewBatchBackend(io.Discard, FormatJson, auditv1beta1.SchemeGroupVersion, BatchOpts{
+		MaxBatchSize:     500,
+		MaxFlushInterval: time.Second,
+	}).(*batchBackend)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	if err := backend.Run(stopCh); err != nil {
+		b.Fatalf("Run() error = %v", err)
+	}
+	defer backend.Shutdown()
+
+	ev := benchmarkEvent()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		backend.ProcessEvents(ev)
+	}
+}
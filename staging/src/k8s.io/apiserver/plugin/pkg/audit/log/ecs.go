@@ -0,0 +1,125 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package log
+
+import (
+	"encoding/json"
+	"time"
+
+	auditinternal "k8s.io/apiserver/pkg/apis/audit"
+)
+
+// ecsEnvelope is what ecsFormatter emits: the event mapped onto the
+// Elastic Common Schema fields a Filebeat/Fluent-Bit pipeline expects,
+// plus the complete original event nested under "kubernetes.audit" so
+// nothing is lost relative to FormatJson.
+type ecsEnvelope struct {
+	Timestamp  string        `json:"@timestamp"`
+	Event      ecsEvent      `json:"event"`
+	Client     *ecsClient    `json:"client,omitempty"`
+	User       *ecsUser      `json:"user,omitempty"`
+	URL        *ecsURL       `json:"url,omitempty"`
+	HTTP       *ecsHTTP      `json:"http,omitempty"`
+	Kubernetes ecsKubernetes `json:"kubernetes"`
+}
+
+type ecsEvent struct {
+	Kind     string   `json:"kind"`
+	Category []string `json:"category"`
+	Action   string   `json:"action,omitempty"`
+	Outcome  string   `json:"outcome,omitempty"`
+}
+
+type ecsClient struct {
+	IP string `json:"ip,omitempty"`
+}
+
+type ecsUser struct {
+	Name      string           `json:"name,omitempty"`
+	Roles     []string         `json:"roles,omitempty"`
+	Effective *ecsUserIdentity `json:"effective,omitempty"`
+}
+
+// ecsUserIdentity is user.effective.*, populated from
+// Event.ImpersonatedUser: the identity the request actually ran as, as
+// opposed to the top-level user.* fields, which are always the credential
+// that made the call.
+type ecsUserIdentity struct {
+	Name  string   `json:"name,omitempty"`
+	Roles []string `json:"roles,omitempty"`
+}
+
+type ecsURL struct {
+	Original string `json:"original,omitempty"`
+}
+
+type ecsHTTP struct {
+	Response ecsHTTPResponse `json:"response"`
+}
+
+type ecsHTTPResponse struct {
+	StatusCode int32 `json:"status_code,omitempty"`
+}
+
+type ecsKubernetes struct {
+	Audit *auditinternal.Event `json:"audit"`
+}
+
+type ecsFormatter struct{}
+
+func (ecsFormatter) Format(ev *auditinternal.Event) ([]byte, error) {
+	envelope := ecsEnvelope{
+		Timestamp: ev.Timestamp.Format(time.RFC3339Nano),
+		Event: ecsEvent{
+			Kind:     "event",
+			Category: []string{"authentication", "configuration"},
+			Action:   ev.Verb,
+		},
+		Kubernetes: ecsKubernetes{Audit: ev},
+	}
+
+	if ev.ResponseStatus != nil {
+		if ev.ResponseStatus.Code < 400 {
+			envelope.Event.Outcome = "success"
+		} else {
+			envelope.Event.Outcome = "failure"
+		}
+		envelope.HTTP = &ecsHTTP{Response: ecsHTTPResponse{StatusCode: ev.ResponseStatus.Code}}
+	}
+
+	if len(ev.SourceIPs) > 0 {
+		envelope.Client = &ecsClient{IP: ev.SourceIPs[0]}
+	}
+
+	if ev.User.Username != "" || len(ev.User.Groups) > 0 || ev.ImpersonatedUser != nil {
+		user := &ecsUser{Name: ev.User.Username, Roles: ev.User.Groups}
+		if ev.ImpersonatedUser != nil {
+			user.Effective = &ecsUserIdentity{Name: ev.ImpersonatedUser.Username, Roles: ev.ImpersonatedUser.Groups}
+		}
+		envelope.User = user
+	}
+
+	if ev.RequestURI != "" {
+		envelope.URL = &ecsURL{Original: ev.RequestURI}
+	}
+
+	bs, err := json.Marshal(envelope)
+	if err != nil {
+		return nil, err
+	}
+	return append(bs, '\n'), nil
+}
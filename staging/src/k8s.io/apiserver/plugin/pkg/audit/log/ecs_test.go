@@ -0,0 +1,136 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	auditinternal "k8s.io/apiserver/pkg/apis/audit"
+	auditv1beta1 "k8s.io/apiserver/pkg/apis/audit/v1beta1"
+)
+
+func TestLogEventsECS(t *testing.T) {
+	for _, event := range []*auditinternal.Event{
+		{
+			AuditID: types.UID("11111111-1111-1111-1111-111111111111"),
+		},
+		{
+			ResponseStatus: &metav1.Status{
+				Code: 200,
+			},
+			ResponseObject: &runtime.Unknown{
+				Raw:         []byte(payload),
+				ContentType: runtime.ContentTypeJSON,
+			},
+			RequestURI: "/apis/rbac.authorization.k8s.io/v1/roles",
+			SourceIPs: []string{
+				"127.0.0.1",
+			},
+			// ECS's @timestamp round-trips through RFC3339Nano, which
+			// drops monotonic-clock data but keeps full precision, unlike
+			// FormatJson's truncation to whole seconds.
+			Timestamp: metav1.NewTime(time.Now()),
+			AuditID:   types.UID("22222222-2222-2222-2222-222222222222"),
+			Stage:     auditinternal.StageRequestReceived,
+			Verb:      "get",
+			User: auditinternal.UserInfo{
+				Username: "admin",
+				Groups: []string{
+					"system:masters",
+					"system:authenticated",
+				},
+			},
+			ImpersonatedUser: &auditinternal.UserInfo{
+				Username: "u-xxxxx",
+				Groups:   []string{"system:authenticated"},
+			},
+			ObjectRef: &auditinternal.ObjectReference{
+				Namespace: "default",
+			},
+		},
+		{
+			AuditID: types.UID("33333333-3333-3333-3333-333333333333"),
+			Verb:    "delete",
+			ResponseStatus: &metav1.Status{
+				Code: 403,
+			},
+		},
+	} {
+		var buf bytes.Buffer
+		backend := NewBackend(&buf, FormatECS, auditv1beta1.SchemeGroupVersion)
+		backend.ProcessEvents(event)
+
+		var envelope ecsEnvelope
+		if err := json.Unmarshal(buf.Bytes(), &envelope); err != nil {
+			t.Fatalf("ECS output isn't valid NDJSON: %v\nline: %s", err, buf.String())
+		}
+
+		if envelope.Event.Kind != "event" {
+			t.Errorf("event.kind = %q, want %q", envelope.Event.Kind, "event")
+		}
+		if envelope.Event.Action != event.Verb {
+			t.Errorf("event.action = %q, want Verb %q", envelope.Event.Action, event.Verb)
+		}
+		if event.ResponseStatus != nil {
+			wantOutcome := "success"
+			if event.ResponseStatus.Code >= 400 {
+				wantOutcome = "failure"
+			}
+			if envelope.Event.Outcome != wantOutcome {
+				t.Errorf("event.outcome = %q, want %q for code %d", envelope.Event.Outcome, wantOutcome, event.ResponseStatus.Code)
+			}
+			if envelope.HTTP == nil || envelope.HTTP.Response.StatusCode != event.ResponseStatus.Code {
+				t.Errorf("http.response.status_code = %+v, want %d", envelope.HTTP, event.ResponseStatus.Code)
+			}
+		}
+		if len(event.SourceIPs) > 0 {
+			if envelope.Client == nil || envelope.Client.IP != event.SourceIPs[0] {
+				t.Errorf("client.ip = %+v, want %q", envelope.Client, event.SourceIPs[0])
+			}
+		}
+		if event.ImpersonatedUser != nil {
+			if envelope.User == nil || envelope.User.Effective == nil || envelope.User.Effective.Name != event.ImpersonatedUser.Username {
+				t.Errorf("user.effective = %+v, want name %q", envelope.User, event.ImpersonatedUser.Username)
+			}
+		}
+		if event.User.Username != "" && (envelope.User == nil || envelope.User.Name != event.User.Username) {
+			t.Errorf("user.name = %+v, want %q", envelope.User, event.User.Username)
+		}
+
+		got := envelope.Kubernetes.Audit
+		if got == nil || got.AuditID != event.AuditID || got.Verb != event.Verb {
+			t.Errorf("kubernetes.audit = %+v, want auditID %q verb %q", got, event.AuditID, event.Verb)
+		}
+		if event.ResponseObject != nil {
+			// encoding/json compacts the embedded raw JSON on the way out.
+			var want bytes.Buffer
+			if err := json.Compact(&want, event.ResponseObject.Raw); err != nil {
+				t.Fatalf("compacting payload: %v", err)
+			}
+			if got.ResponseObject == nil || !reflect.DeepEqual(got.ResponseObject.Raw, want.Bytes()) {
+				t.Errorf("kubernetes.audit.responseObject should round-trip the full original payload, compacted")
+			}
+		}
+	}
+}
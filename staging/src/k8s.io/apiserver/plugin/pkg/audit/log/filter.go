@@ -0,0 +1,379 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package log
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	auditinternal "k8s.io/apiserver/pkg/apis/audit"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+// Filter decides whether an event should continue on to a Sampler and the
+// rest of the log backend's pipeline, or be dropped outright.
+type Filter interface {
+	Accept(ev *auditinternal.Event) bool
+}
+
+// FilterOp is a comparison a FilterExpr leaf applies to an event field.
+type FilterOp string
+
+const (
+	// FilterOpEq matches when the field equals Value.
+	FilterOpEq FilterOp = "eq"
+	// FilterOpNeq matches when the field does not equal Value.
+	FilterOpNeq FilterOp = "neq"
+	// FilterOpIn matches when the field equals any entry of Values.
+	FilterOpIn FilterOp = "in"
+	// FilterOpContains matches when the field contains Value as a substring.
+	FilterOpContains FilterOp = "contains"
+)
+
+// filterFields are the event fields a FilterExpr leaf may name.
+var filterFields = map[string]bool{
+	"verb":                true,
+	"user.username":       true,
+	"user.groups":         true,
+	"objectRef.resource":  true,
+	"objectRef.namespace": true,
+	"responseStatus.code": true,
+	"stage":               true,
+	"level":               true,
+}
+
+// FilterExpr is one node of the filter predicate DSL: either a leaf
+// comparing Field against Value/Values with Op, or a boolean combinator
+// (And/Or/Not) over nested expressions. Exactly one of (Field set), And,
+// Or, Not should be populated; the zero value matches nothing.
+type FilterExpr struct {
+	// Field is one of verb, user.username, user.groups,
+	// objectRef.resource, objectRef.namespace, responseStatus.code,
+	// stage, or level. user.groups matches if any of the user's groups
+	// satisfies the comparison.
+	Field string   `json:"field,omitempty"`
+	Op    FilterOp `json:"op,omitempty"`
+	// Value is the operand for eq/neq/contains.
+	Value string `json:"value,omitempty"`
+	// Values is the operand for in.
+	Values []string `json:"values,omitempty"`
+
+	And []FilterExpr `json:"and,omitempty"`
+	Or  []FilterExpr `json:"or,omitempty"`
+	Not *FilterExpr  `json:"not,omitempty"`
+}
+
+func (e FilterExpr) isLeaf() bool {
+	return e.Field != ""
+}
+
+func (e FilterExpr) isZero() bool {
+	return e.Field == "" && len(e.And) == 0 && len(e.Or) == 0 && e.Not == nil
+}
+
+// Matches evaluates the expression tree against ev.
+func (e FilterExpr) Matches(ev *auditinternal.Event) bool {
+	switch {
+	case len(e.And) > 0:
+		for _, sub := range e.And {
+			if !sub.Matches(ev) {
+				return false
+			}
+		}
+		return true
+	case len(e.Or) > 0:
+		for _, sub := range e.Or {
+			if sub.Matches(ev) {
+				return true
+			}
+		}
+		return false
+	case e.Not != nil:
+		return !e.Not.Matches(ev)
+	case e.isLeaf():
+		return e.matchesLeaf(eventFieldValues(ev, e.Field))
+	default:
+		return false
+	}
+}
+
+func (e FilterExpr) matchesLeaf(values []string) bool {
+	switch e.Op {
+	case FilterOpNeq:
+		for _, v := range values {
+			if v == e.Value {
+				return false
+			}
+		}
+		return true
+	case FilterOpIn:
+		for _, v := range values {
+			for _, want := range e.Values {
+				if v == want {
+					return true
+				}
+			}
+		}
+		return false
+	case FilterOpContains:
+		for _, v := range values {
+			if strings.Contains(v, e.Value) {
+				return true
+			}
+		}
+		return false
+	default: // FilterOpEq
+		for _, v := range values {
+			if v == e.Value {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// eventFieldValues returns field's value(s) from ev: a single entry for
+// scalar fields, or one per group for user.groups, or none when the field
+// or an object it's nested under wasn't set on ev.
+func eventFieldValues(ev *auditinternal.Event, field string) []string {
+	switch field {
+	case "verb":
+		return []string{ev.Verb}
+	case "stage":
+		return []string{string(ev.Stage)}
+	case "level":
+		return []string{string(ev.Level)}
+	case "user.username":
+		return []string{ev.User.Username}
+	case "user.groups":
+		return ev.User.Groups
+	case "objectRef.resource":
+		if ev.ObjectRef == nil {
+			return nil
+		}
+		return []string{ev.ObjectRef.Resource}
+	case "objectRef.namespace":
+		if ev.ObjectRef == nil {
+			return nil
+		}
+		return []string{ev.ObjectRef.Namespace}
+	case "responseStatus.code":
+		if ev.ResponseStatus == nil {
+			return nil
+		}
+		return []string{strconv.Itoa(int(ev.ResponseStatus.Code))}
+	default:
+		return nil
+	}
+}
+
+// FilterPolicy rejects events matching Reject before they reach a Sampler
+// or Formatter. The zero value rejects nothing.
+type FilterPolicy struct {
+	Reject FilterExpr `json:"reject,omitempty"`
+}
+
+// Accept implements Filter.
+func (p FilterPolicy) Accept(ev *auditinternal.Event) bool {
+	if p.Reject.isZero() {
+		return true
+	}
+	return !p.Reject.Matches(ev)
+}
+
+var _ Filter = FilterPolicy{}
+
+// LoadFilterPolicy parses a FilterPolicy from YAML (or JSON, which is
+// valid YAML), validating every leaf's Field and Op up front so a
+// malformed policy fails at startup rather than on the first event it
+// should have filtered.
+func LoadFilterPolicy(data []byte) (FilterPolicy, error) {
+	var policy FilterPolicy
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return FilterPolicy{}, fmt.Errorf("parsing filter policy: %w", err)
+	}
+	if err := policy.Reject.validate(); err != nil {
+		return FilterPolicy{}, fmt.Errorf("filter policy: %w", err)
+	}
+	return policy, nil
+}
+
+func (e FilterExpr) validate() error {
+	switch {
+	case len(e.And) > 0:
+		for i := range e.And {
+			if err := e.And[i].validate(); err != nil {
+				return fmt.Errorf("and[%d]: %w", i, err)
+			}
+		}
+		return nil
+	case len(e.Or) > 0:
+		for i := range e.Or {
+			if err := e.Or[i].validate(); err != nil {
+				return fmt.Errorf("or[%d]: %w", i, err)
+			}
+		}
+		return nil
+	case e.Not != nil:
+		return e.Not.validate()
+	case e.isLeaf():
+		if !filterFields[e.Field] {
+			return fmt.Errorf("unknown field %q", e.Field)
+		}
+		switch e.Op {
+		case FilterOpEq, FilterOpNeq, FilterOpIn, FilterOpContains:
+		default:
+			return fmt.Errorf("field %q: unknown op %q", e.Field, e.Op)
+		}
+		return nil
+	default:
+		return nil
+	}
+}
+
+// SamplingPolicy caps the rate of events a Sampler keeps after they pass a
+// Filter: a uniform SampleRate, plus independent per-verb and per-user
+// token-bucket rate limits. An event must clear every limit it's subject
+// to in order to be kept.
+type SamplingPolicy struct {
+	// SampleRate is the fraction of events, in (0,1], kept. The zero
+	// value disables uniform sampling (every event passes this check);
+	// to drop an event type entirely, reject it with a FilterPolicy
+	// instead.
+	SampleRate float64 `json:"sampleRate,omitempty"`
+	// PerVerbQPS caps events to at most this many per second, keyed by
+	// verb (e.g. {"list": 1} to allow one list event per second).
+	PerVerbQPS map[string]float64 `json:"perVerbQPS,omitempty"`
+	// PerUserQPS caps events to at most this many per second, keyed by
+	// user.username.
+	PerUserQPS map[string]float64 `json:"perUserQPS,omitempty"`
+}
+
+// Sampler applies a SamplingPolicy, tracking the token buckets its
+// per-verb/per-user limits need across calls. The zero value has no
+// SamplingPolicy and keeps every event.
+type Sampler struct {
+	policy SamplingPolicy
+	rand   func() float64
+
+	mu          sync.Mutex
+	verbBuckets map[string]*tokenBucket
+	userBuckets map[string]*tokenBucket
+}
+
+// NewSampler returns a Sampler enforcing policy.
+func NewSampler(policy SamplingPolicy) *Sampler {
+	return &Sampler{
+		policy:      policy,
+		rand:        rand.Float64,
+		verbBuckets: make(map[string]*tokenBucket),
+		userBuckets: make(map[string]*tokenBucket),
+	}
+}
+
+// Sample reports whether ev should be kept. A nil Sampler keeps everything.
+func (s *Sampler) Sample(ev *auditinternal.Event) bool {
+	if s == nil {
+		return true
+	}
+	if rate := s.policy.SampleRate; rate > 0 && rate < 1 && s.rand() >= rate {
+		return false
+	}
+	if qps, ok := s.policy.PerVerbQPS[ev.Verb]; ok && !s.bucketFor(s.verbBuckets, ev.Verb, qps).Allow() {
+		return false
+	}
+	if qps, ok := s.policy.PerUserQPS[ev.User.Username]; ok && !s.bucketFor(s.userBuckets, ev.User.Username, qps).Allow() {
+		return false
+	}
+	return true
+}
+
+func (s *Sampler) bucketFor(buckets map[string]*tokenBucket, key string, qps float64) *tokenBucket {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := buckets[key]
+	if !ok {
+		b = newTokenBucket(qps)
+		buckets[key] = b
+	}
+	return b
+}
+
+// tokenBucket is a continuously-refilling rate limiter: it holds at most
+// qps tokens (one second of burst) and refills at qps tokens/second.
+type tokenBucket struct {
+	qps float64
+	now func() time.Time
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(qps float64) *tokenBucket {
+	return &tokenBucket{qps: qps, now: time.Now, tokens: qps, last: time.Now()}
+}
+
+// Allow reports whether a token is available and, if so, consumes one.
+func (b *tokenBucket) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * b.qps
+		if b.tokens > b.qps {
+			b.tokens = b.qps
+		}
+		b.last = now
+	}
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// auditFilterEventsTotal counts how logEvent's Filter and Sampler disposed
+// of events, partitioned by result, for operators to tell "nothing is
+// happening" apart from "my filter is dropping everything".
+var auditFilterEventsTotal = metrics.NewCounterVec(
+	&metrics.CounterOpts{
+		Subsystem:      "audit",
+		Name:           "log_backend_events_total",
+		Help:           "Number of audit events the log backend's Filter and Sampler accepted, rejected, or sampled away, partitioned by result.",
+		StabilityLevel: metrics.ALPHA,
+	},
+	[]string{"result"},
+)
+
+var registerFilterMetricsOnce sync.Once
+
+// registerFilterMetrics registers auditFilterEventsTotal with the legacy
+// registry. Safe to call more than once.
+func registerFilterMetrics() {
+	registerFilterMetricsOnce.Do(func() {
+		legacyregistry.MustRegister(auditFilterEventsTotal)
+	})
+}
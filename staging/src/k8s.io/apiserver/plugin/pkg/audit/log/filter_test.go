@@ -0,0 +1,225 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package log
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	auditinternal "k8s.io/apiserver/pkg/apis/audit"
+	"k8s.io/component-base/metrics/testutil"
+)
+
+// deleteCollectionPodsEvent mirrors the deletecollection-on-pods event
+// described by this chunk's embedded payload, the kind of noisy,
+// high-volume event an operator would want a FilterPolicy to drop.
+func deleteCollectionPodsEvent() *auditinternal.Event {
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(payload), &raw); err != nil {
+		panic(err)
+	}
+	return &auditinternal.Event{
+		Verb: raw["verb"].(string),
+		User: auditinternal.UserInfo{
+			Username: raw["user"].(map[string]interface{})["username"].(string),
+		},
+		ObjectRef: &auditinternal.ObjectReference{
+			Resource:  "pods",
+			Namespace: "kubemark",
+		},
+		ResponseStatus: &metav1.Status{Code: 200},
+	}
+}
+
+func TestFilterPolicyRejectsMatchingEvents(t *testing.T) {
+	policy := FilterPolicy{Reject: FilterExpr{
+		And: []FilterExpr{
+			{Field: "verb", Op: FilterOpEq, Value: "deletecollection"},
+			{Field: "objectRef.resource", Op: FilterOpEq, Value: "pods"},
+		},
+	}}
+
+	if policy.Accept(deleteCollectionPodsEvent()) {
+		t.Error("Accept() = true, want the deletecollection/pods event rejected")
+	}
+	if !policy.Accept(&auditinternal.Event{Verb: "get", ObjectRef: &auditinternal.ObjectReference{Resource: "pods"}}) {
+		t.Error("Accept() = false, want a get on pods to pass through")
+	}
+}
+
+func TestFilterPolicyZeroValueAcceptsEverything(t *testing.T) {
+	var policy FilterPolicy
+	if !policy.Accept(deleteCollectionPodsEvent()) {
+		t.Error("zero-value FilterPolicy should accept every event")
+	}
+}
+
+func TestFilterExprOr(t *testing.T) {
+	expr := FilterExpr{Or: []FilterExpr{
+		{Field: "verb", Op: FilterOpEq, Value: "watch"},
+		{Field: "responseStatus.code", Op: FilterOpEq, Value: "404"},
+	}}
+
+	if !expr.Matches(&auditinternal.Event{Verb: "watch"}) {
+		t.Error("Or should match when the first branch matches")
+	}
+	if !expr.Matches(&auditinternal.Event{ResponseStatus: &metav1.Status{Code: 404}}) {
+		t.Error("Or should match when the second branch matches")
+	}
+	if expr.Matches(&auditinternal.Event{Verb: "get"}) {
+		t.Error("Or should not match when neither branch matches")
+	}
+}
+
+func TestFilterExprNot(t *testing.T) {
+	expr := FilterExpr{Not: &FilterExpr{Field: "verb", Op: FilterOpEq, Value: "get"}}
+	if expr.Matches(&auditinternal.Event{Verb: "get"}) {
+		t.Error("Not should invert a matching inner expression")
+	}
+	if !expr.Matches(&auditinternal.Event{Verb: "list"}) {
+		t.Error("Not should invert a non-matching inner expression")
+	}
+}
+
+func TestFilterExprInMatchesAnyUserGroup(t *testing.T) {
+	expr := FilterExpr{Field: "user.groups", Op: FilterOpIn, Values: []string{"system:masters"}}
+	ev := &auditinternal.Event{User: auditinternal.UserInfo{Groups: []string{"system:authenticated", "system:masters"}}}
+	if !expr.Matches(ev) {
+		t.Error("in should match when any group is in Values")
+	}
+}
+
+func TestLoadFilterPolicyRejectsUnknownField(t *testing.T) {
+	_, err := LoadFilterPolicy([]byte(`
+reject:
+  field: nonexistent
+  op: eq
+  value: x
+`))
+	if err == nil || !strings.Contains(err.Error(), "unknown field") {
+		t.Errorf("LoadFilterPolicy() error = %v, want an unknown field error", err)
+	}
+}
+
+func TestLoadFilterPolicyRejectsUnknownOp(t *testing.T) {
+	_, err := LoadFilterPolicy([]byte(`
+reject:
+  field: verb
+  op: matches
+  value: x
+`))
+	if err == nil || !strings.Contains(err.Error(), "unknown op") {
+		t.Errorf("LoadFilterPolicy() error = %v, want an unknown op error", err)
+	}
+}
+
+func TestSamplerSampleRateKeepsNoneAboveThreshold(t *testing.T) {
+	s := NewSampler(SamplingPolicy{SampleRate: 0.5})
+	s.rand = func() float64 { return 0.9 }
+	if s.Sample(&auditinternal.Event{}) {
+		t.Error("Sample() = true, want dropped when rand() >= SampleRate")
+	}
+	s.rand = func() float64 { return 0.1 }
+	if !s.Sample(&auditinternal.Event{}) {
+		t.Error("Sample() = false, want kept when rand() < SampleRate")
+	}
+}
+
+func TestSamplerNilIsNoop(t *testing.T) {
+	var s *Sampler
+	if !s.Sample(&auditinternal.Event{}) {
+		t.Error("nil Sampler should keep every event")
+	}
+}
+
+func TestSamplerPerVerbQPSThrottles(t *testing.T) {
+	s := NewSampler(SamplingPolicy{PerVerbQPS: map[string]float64{"list": 1}})
+	cur := time.Unix(0, 0)
+	s.verbBuckets["list"] = &tokenBucket{qps: 1, now: func() time.Time { return cur }, tokens: 1, last: cur}
+
+	ev := &auditinternal.Event{Verb: "list"}
+	if !s.Sample(ev) {
+		t.Fatal("first list event should be kept (burst of 1)")
+	}
+	if s.Sample(ev) {
+		t.Fatal("second immediate list event should be throttled")
+	}
+	cur = cur.Add(time.Second)
+	if !s.Sample(ev) {
+		t.Error("list event one second later should be kept again")
+	}
+}
+
+func TestTokenBucketRefillsOverTime(t *testing.T) {
+	cur := time.Unix(0, 0)
+	b := &tokenBucket{qps: 2, now: func() time.Time { return cur }, tokens: 2, last: cur}
+
+	if !b.Allow() || !b.Allow() {
+		t.Fatal("burst of 2 tokens should both be allowed immediately")
+	}
+	if b.Allow() {
+		t.Fatal("third immediate call should be throttled")
+	}
+	cur = cur.Add(500 * time.Millisecond)
+	if !b.Allow() {
+		t.Error("half a second at 2 qps should refill exactly one token")
+	}
+	if b.Allow() {
+		t.Error("no more tokens should be available yet")
+	}
+}
+
+// filterEventsCount reads auditFilterEventsTotal's current value for result,
+// registering the filter metrics first if no NewBackend call has yet.
+func filterEventsCount(t *testing.T, result string) float64 {
+	t.Helper()
+	registerFilterMetrics()
+	v, err := testutil.GetCounterMetricValue(auditFilterEventsTotal.WithLabelValues(result))
+	if err != nil {
+		t.Fatalf("reading audit_log_backend_events_total{result=%q}: %v", result, err)
+	}
+	return v
+}
+
+func TestFilterStatsCountAcceptedRejectedAndSampled(t *testing.T) {
+	b := &backend{
+		filter: FilterPolicy{Reject: FilterExpr{Field: "verb", Op: FilterOpEq, Value: "deletecollection"}},
+		sampler: func() *Sampler {
+			s := NewSampler(SamplingPolicy{SampleRate: 0.5})
+			s.rand = func() float64 { return 0.9 }
+			return s
+		}(),
+	}
+
+	accepted, rejected, sampled := filterEventsCount(t, "accepted"), filterEventsCount(t, "rejected"), filterEventsCount(t, "sampled")
+
+	b.logEvent(&auditinternal.Event{Verb: "deletecollection"})
+	b.logEvent(&auditinternal.Event{Verb: "get"})
+
+	if got := filterEventsCount(t, "rejected") - rejected; got != 1 {
+		t.Errorf("rejected events increased by %v, want 1", got)
+	}
+	if got := filterEventsCount(t, "sampled") - sampled; got != 1 {
+		t.Errorf("sampled events increased by %v, want 1", got)
+	}
+	if got := filterEventsCount(t, "accepted") - accepted; got != 0 {
+		t.Errorf("accepted events increased by %v, want 0", got)
+	}
+}
@@ -0,0 +1,243 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	auditinternal "k8s.io/apiserver/pkg/apis/audit"
+)
+
+// FormatLogfmt flattens an event to one key=value line, with nested fields
+// addressed by dotted path (objectRef.resource, user.username, ...).
+const FormatLogfmt = "logfmt"
+
+// FormatStructured emits one NDJSON line per event, promoting the fields
+// most audit queries filter or group by (verb, user, resource, namespace,
+// stage, code, auditID) to top-level keys while keeping the full event -
+// including requestObject/responseObject - nested underneath.
+const FormatStructured = "structured"
+
+// FormatECS emits one NDJSON line per event, mapped onto the Elastic
+// Common Schema (see ecs.go), so a Filebeat/Fluent-Bit shipper can land
+// events in Elasticsearch/OpenSearch without a custom ingest pipeline.
+const FormatECS = "ecs"
+
+// Formatter renders a single audit event as the bytes logEvent should write
+// for it, including any trailing newline.
+type Formatter interface {
+	Format(ev *auditinternal.Event) ([]byte, error)
+}
+
+// newFormatter returns the Formatter for format, using encoder to render
+// FormatJson's canonical encoding.
+func newFormatter(format string, encoder runtime.Encoder) (Formatter, error) {
+	switch format {
+	case FormatLegacy:
+		return legacyFormatter{}, nil
+	case FormatJson:
+		return jsonFormatter{encoder: encoder}, nil
+	case FormatLogfmt:
+		return logfmtFormatter{}, nil
+	case FormatStructured:
+		return structuredFormatter{encoder: encoder}, nil
+	case FormatECS:
+		return ecsFormatter{}, nil
+	default:
+		return nil, fmt.Errorf("log backend does not support format %q, must be one of %v", format, AllowedFormats)
+	}
+}
+
+type legacyFormatter struct{}
+
+func (legacyFormatter) Format(ev *auditinternal.Event) ([]byte, error) {
+	return []byte(legacyEventString(ev) + "\n"), nil
+}
+
+// legacyEventString renders ev as a single human-readable line, the same
+// shape audit.EventString has always produced, except that as=/asgroups=
+// report the real impersonated identity when Event.ImpersonatedUser is
+// set instead of always printing the "<self>"/"<lookup>" placeholders -
+// those were never accurate for a request made through impersonation, the
+// primary authorization boundary on multi-tenant clusters.
+func legacyEventString(ev *auditinternal.Event) string {
+	username := "<none>"
+	groups := "<none>"
+	if len(ev.User.Username) > 0 {
+		username = ev.User.Username
+		groups = auditStringSlice(ev.User.Groups)
+	}
+
+	asuser := "<self>"
+	asgroups := "<lookup>"
+	if ev.ImpersonatedUser != nil {
+		asuser = ev.ImpersonatedUser.Username
+		asgroups = auditStringSlice(ev.ImpersonatedUser.Groups)
+	}
+
+	namespace := "<none>"
+	if ev.ObjectRef != nil {
+		namespace = ev.ObjectRef.Namespace
+	}
+
+	ip := "<unknown>"
+	if len(ev.SourceIPs) > 0 {
+		ip = ev.SourceIPs[0]
+	}
+
+	response := "<deferred>"
+	if ev.ResponseStatus != nil {
+		response = strconv.Itoa(int(ev.ResponseStatus.Code))
+	}
+
+	return fmt.Sprintf("%s AUDIT: id=%q stage=%q ip=%q method=%q user=%q groups=%q as=%q asgroups=%q namespace=%q uri=%q response=%q",
+		ev.Timestamp.Format(time.RFC3339Nano), ev.AuditID, ev.Stage, ip, ev.Verb, username, groups, asuser, asgroups, namespace, ev.RequestURI, response)
+}
+
+// auditStringSlice renders a string slice the way legacyEventString wants
+// its quoted, comma-separated fields rendered, with "<none>" for empty.
+func auditStringSlice(s []string) string {
+	if len(s) == 0 {
+		return "<none>"
+	}
+	quoted := make([]string, len(s))
+	for i, v := range s {
+		quoted[i] = strconv.Quote(v)
+	}
+	return strings.Join(quoted, ",")
+}
+
+type jsonFormatter struct {
+	encoder runtime.Encoder
+}
+
+func (f jsonFormatter) Format(ev *auditinternal.Event) ([]byte, error) {
+	bs, err := runtime.Encode(f.encoder, ev)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(string(bs), "\n") {
+		bs = append(bs, '\n')
+	}
+	return bs, nil
+}
+
+type logfmtFormatter struct{}
+
+func (logfmtFormatter) Format(ev *auditinternal.Event) ([]byte, error) {
+	var b strings.Builder
+	first := true
+	write := func(key, value string) {
+		if value == "" {
+			return
+		}
+		if !first {
+			b.WriteByte(' ')
+		}
+		first = false
+		b.WriteString(key)
+		b.WriteByte('=')
+		b.WriteString(logfmtQuote(value))
+	}
+
+	write("auditID", string(ev.AuditID))
+	write("level", string(ev.Level))
+	write("stage", string(ev.Stage))
+	write("requestURI", ev.RequestURI)
+	write("verb", ev.Verb)
+	write("user.username", ev.User.Username)
+	write("user.uid", ev.User.UID)
+	write("user.groups", strings.Join(ev.User.Groups, ","))
+	write("sourceIPs", strings.Join(ev.SourceIPs, ","))
+	if ev.ObjectRef != nil {
+		write("objectRef.resource", ev.ObjectRef.Resource)
+		write("objectRef.subresource", ev.ObjectRef.Subresource)
+		write("objectRef.namespace", ev.ObjectRef.Namespace)
+		write("objectRef.name", ev.ObjectRef.Name)
+		write("objectRef.apiVersion", ev.ObjectRef.APIVersion)
+	}
+	if ev.ResponseStatus != nil {
+		write("responseStatus.code", fmt.Sprintf("%d", ev.ResponseStatus.Code))
+		write("responseStatus.reason", string(ev.ResponseStatus.Reason))
+	}
+
+	b.WriteByte('\n')
+	return []byte(b.String()), nil
+}
+
+// logfmtQuote wraps value in double quotes, escaping embedded quotes,
+// whenever it contains a space or a double quote - the two characters that
+// would otherwise make a logfmt line ambiguous to split back into fields.
+func logfmtQuote(value string) string {
+	if !strings.ContainsAny(value, ` "`) {
+		return value
+	}
+	return `"` + strings.ReplaceAll(value, `"`, `\"`) + `"`
+}
+
+// structuredEnvelope is what structuredFormatter emits: the event fields a
+// log query engine most commonly filters or groups audit events by,
+// promoted to top level, plus the complete original event (including
+// requestObject/responseObject) nested under "event" so nothing is lost
+// relative to FormatJson.
+//
+// It marshals the internal auditinternal.Event directly with encoding/json
+// rather than going through audit.Codecs - this is an additional,
+// query-oriented view of the event, not the canonical encodeable
+// representation, which remains FormatJson's job.
+type structuredEnvelope struct {
+	AuditID   string               `json:"auditID"`
+	Stage     string               `json:"stage"`
+	Verb      string               `json:"verb"`
+	User      string               `json:"user"`
+	Resource  string               `json:"resource,omitempty"`
+	Namespace string               `json:"namespace,omitempty"`
+	Code      int32                `json:"code,omitempty"`
+	Event     *auditinternal.Event `json:"event"`
+}
+
+type structuredFormatter struct {
+	encoder runtime.Encoder
+}
+
+func (f structuredFormatter) Format(ev *auditinternal.Event) ([]byte, error) {
+	envelope := structuredEnvelope{
+		AuditID: string(ev.AuditID),
+		Stage:   string(ev.Stage),
+		Verb:    ev.Verb,
+		User:    ev.User.Username,
+		Event:   ev,
+	}
+	if ev.ObjectRef != nil {
+		envelope.Resource = ev.ObjectRef.Resource
+		envelope.Namespace = ev.ObjectRef.Namespace
+	}
+	if ev.ResponseStatus != nil {
+		envelope.Code = ev.ResponseStatus.Code
+	}
+
+	bs, err := json.Marshal(envelope)
+	if err != nil {
+		return nil, err
+	}
+	return append(bs, '\n'), nil
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	auditinternal "k8s.io/apiserver/pkg/apis/audit"
+)
+
+// deleteCollectionEvent mirrors the deletecollection-on-pods event this
+// chunk's embedded payload describes: a request whose responseObject is a
+// large PodList, exercising the same fields TestLogEventsLegacy/Json do.
+func deleteCollectionEvent() *auditinternal.Event {
+	return &auditinternal.Event{
+		AuditID: types.UID("36384c8a-1394-4c92-8726-904e4c442b7d"),
+		Stage:   auditinternal.StageResponseComplete,
+		Verb:    "deletecollection",
+		User: auditinternal.UserInfo{
+			Username: "system:serviceaccount:kube-system:namespace-controller",
+			Groups:   []string{"system:serviceaccounts", "system:authenticated"},
+		},
+		ObjectRef: &auditinternal.ObjectReference{
+			Resource:   "pods",
+			Namespace:  "kubemark",
+			APIVersion: "v1",
+		},
+		ResponseStatus: &metav1.Status{Code: 200},
+		ResponseObject: &runtime.Unknown{
+			Raw:         []byte(payload),
+			ContentType: runtime.ContentTypeJSON,
+		},
+	}
+}
+
+func TestLogfmtFormatterFlattensNestedFields(t *testing.T) {
+	formatter := logfmtFormatter{}
+	line, err := formatter.Format(deleteCollectionEvent())
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+
+	got := string(line)
+	for _, want := range []string{
+		`auditID=36384c8a-1394-4c92-8726-904e4c442b7d`,
+		`stage=ResponseComplete`,
+		`verb=deletecollection`,
+		`user.username=system:serviceaccount:kube-system:namespace-controller`,
+		`objectRef.resource=pods`,
+		`objectRef.namespace=kubemark`,
+		`responseStatus.code=200`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("logfmt line missing %q, got: %s", want, got)
+		}
+	}
+	if strings.Contains(got, "\n\n") || !strings.HasSuffix(got, "\n") {
+		t.Errorf("logfmt line should be a single line terminated by one newline, got: %q", got)
+	}
+}
+
+func TestLogfmtFormatterQuotesValuesWithSpaces(t *testing.T) {
+	ev := &auditinternal.Event{RequestURI: "/api/v1/namespaces/has space/pods"}
+	line, err := (logfmtFormatter{}).Format(ev)
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+	if !strings.Contains(string(line), `requestURI="/api/v1/namespaces/has space/pods"`) {
+		t.Errorf("value containing a space should be quoted, got: %s", line)
+	}
+}
+
+func TestStructuredFormatterPromotesFieldsAndKeepsFullEventNested(t *testing.T) {
+	formatter := structuredFormatter{}
+	line, err := formatter.Format(deleteCollectionEvent())
+	if err != nil {
+		t.Fatalf("Format() error = %v", err)
+	}
+
+	var got structuredEnvelope
+	if err := json.Unmarshal(line, &got); err != nil {
+		t.Fatalf("structured output isn't valid NDJSON: %v\nline: %s", err, line)
+	}
+
+	if got.AuditID != "36384c8a-1394-4c92-8726-904e4c442b7d" {
+		t.Errorf("auditID = %q, want the promoted top-level value", got.AuditID)
+	}
+	if got.Verb != "deletecollection" || got.Resource != "pods" || got.Namespace != "kubemark" || got.Code != 200 {
+		t.Errorf("promoted fields = %+v, want verb/resource/namespace/code from the event", got)
+	}
+	// encoding/json compacts the embedded raw JSON, so the nested copy is
+	// the payload minus its insignificant whitespace.
+	var compacted bytes.Buffer
+	if err := json.Compact(&compacted, []byte(payload)); err != nil {
+		t.Fatalf("compacting payload: %v", err)
+	}
+	if got.Event == nil || got.Event.ResponseObject == nil || len(got.Event.ResponseObject.Raw) != compacted.Len() {
+		t.Errorf("nested event.responseObject should retain the full, untruncated payload")
+	}
+}
@@ -0,0 +1,432 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package log
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	auditinternal "k8s.io/apiserver/pkg/apis/audit"
+)
+
+// RedactionStrategy is how a matched value is rewritten by a RedactionRule.
+type RedactionStrategy string
+
+const (
+	// RedactDrop removes the matched field entirely.
+	RedactDrop RedactionStrategy = "drop"
+	// RedactHash replaces the matched value with the hex SHA-256 digest of
+	// HashSalt plus the value, preserving joinability across events without
+	// keeping the original value recoverable.
+	RedactHash RedactionStrategy = "hash"
+	// RedactMask replaces the matched value with the literal "***".
+	RedactMask RedactionStrategy = "mask"
+	// RedactKeepLastN replaces every character but the trailing
+	// KeepLastNChars of the matched value with "*".
+	RedactKeepLastN RedactionStrategy = "keep-last-n"
+)
+
+// RedactionRule selects zero or more fields of a RequestObject or
+// ResponseObject and rewrites the values they resolve to.
+type RedactionRule struct {
+	// Selector is a JSONPath-style field selector rooted at either
+	// "requestObject" or "responseObject", e.g.
+	// "responseObject.items[*].spec.containers[*].env[*].value" or
+	// "requestObject.data.*". "[*]" walks every element of an array field,
+	// and a bare "*" segment walks every key of a map field.
+	Selector string `json:"selector"`
+	// ValueRegex, when set, restricts the rule to string values matching
+	// this expression. Unset matches every value the selector resolves to.
+	ValueRegex string `json:"valueRegex,omitempty"`
+	// Strategy is how matched values are rewritten.
+	Strategy RedactionStrategy `json:"strategy"`
+	// HashSalt salts RedactHash's digest.
+	HashSalt string `json:"hashSalt,omitempty"`
+	// HashPrefix is prepended to RedactHash's digest, e.g. "sha256:" to mark
+	// which algorithm produced it. Empty leaves the digest unprefixed.
+	HashPrefix string `json:"hashPrefix,omitempty"`
+	// HashLength truncates RedactHash's hex digest to this many characters.
+	// Zero keeps the full digest.
+	HashLength int `json:"hashLength,omitempty"`
+	// KeepLastNChars is the number of trailing characters RedactKeepLastN
+	// preserves.
+	KeepLastNChars int `json:"keepLastNChars,omitempty"`
+
+	valueRegex *regexp.Regexp
+}
+
+// RedactionPolicy is the set of rules logEvent runs over a event's
+// RequestObject and ResponseObject before it's handed to a Formatter. The
+// zero value disables redaction.
+type RedactionPolicy struct {
+	Rules []RedactionRule `json:"rules"`
+}
+
+// LoadRedactionPolicy parses a RedactionPolicy from YAML (or JSON, which is
+// valid YAML), compiling and validating every rule's ValueRegex and
+// Strategy up front so a malformed policy fails at startup rather than on
+// the first matching event.
+func LoadRedactionPolicy(data []byte) (RedactionPolicy, error) {
+	var policy RedactionPolicy
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return RedactionPolicy{}, fmt.Errorf("parsing redaction policy: %w", err)
+	}
+	for i := range policy.Rules {
+		rule := &policy.Rules[i]
+		switch rule.Strategy {
+		case RedactDrop, RedactHash, RedactMask, RedactKeepLastN:
+		default:
+			return RedactionPolicy{}, fmt.Errorf("redaction rule %d: unknown strategy %q", i, rule.Strategy)
+		}
+		if rule.ValueRegex != "" {
+			re, err := regexp.Compile(rule.ValueRegex)
+			if err != nil {
+				return RedactionPolicy{}, fmt.Errorf("redaction rule %d: compiling valueRegex %q: %w", i, rule.ValueRegex, err)
+			}
+			rule.valueRegex = re
+		}
+	}
+	return policy, nil
+}
+
+// redactEvent returns ev unchanged if policy has no rules or none of them
+// match. Otherwise it returns a shallow copy of ev with RequestObject and/or
+// ResponseObject replaced by a re-marshaled copy carrying the redactions,
+// leaving the caller's original event - which other backends in the same
+// union may also be processing - untouched.
+func redactEvent(ev *auditinternal.Event, policy RedactionPolicy) *auditinternal.Event {
+	if len(policy.Rules) == 0 {
+		return ev
+	}
+
+	req, reqChanged := redactObject(ev.RequestObject, "requestObject", policy.Rules)
+	resp, respChanged := redactObject(ev.ResponseObject, "responseObject", policy.Rules)
+	if !reqChanged && !respChanged {
+		return ev
+	}
+
+	out := *ev
+	out.RequestObject = req
+	out.ResponseObject = resp
+	return &out
+}
+
+// redactObject applies every rule rooted at root to obj's parsed JSON,
+// reporting whether any rule matched. Objects that aren't valid JSON, or
+// whose Raw is empty, are returned unchanged - there's nothing to walk.
+func redactObject(obj *runtime.Unknown, root string, rules []RedactionRule) (*runtime.Unknown, bool) {
+	if obj == nil || len(obj.Raw) == 0 {
+		return obj, false
+	}
+
+	var parsed interface{}
+	if err := json.Unmarshal(obj.Raw, &parsed); err != nil {
+		return obj, false
+	}
+
+	changed := false
+	for _, rule := range rules {
+		segments := splitSelector(rule.Selector)
+		if len(segments) < 2 || segments[0] != root {
+			continue
+		}
+		// Rules built in code rather than by LoadRedactionPolicy carry
+		// ValueRegex uncompiled; a rule whose regex doesn't compile is skipped.
+		valueRegex := rule.valueRegex
+		if valueRegex == nil && rule.ValueRegex != "" {
+			re, err := regexp.Compile(rule.ValueRegex)
+			if err != nil {
+				continue
+			}
+			valueRegex = re
+		}
+		for _, ref := range collectRefs(parsed, segments[1:]) {
+			current := ref.get()
+			if valueRegex != nil {
+				s, ok := current.(string)
+				if !ok || !valueRegex.MatchString(s) {
+					continue
+				}
+			}
+			if newValue, drop := redactValue(rule, current); drop {
+				ref.delete()
+			} else {
+				ref.set(newValue)
+			}
+			changed = true
+		}
+	}
+	if !changed {
+		return obj, false
+	}
+
+	raw, err := json.Marshal(parsed)
+	if err != nil {
+		return obj, false
+	}
+	out := *obj
+	out.Raw = raw
+	return &out, true
+}
+
+// redactValue rewrites value per rule.Strategy. drop reports that the field
+// should be removed from its container rather than set to newValue.
+func redactValue(rule RedactionRule, value interface{}) (newValue interface{}, drop bool) {
+	switch rule.Strategy {
+	case RedactDrop:
+		return nil, true
+	case RedactMask:
+		return "***", false
+	case RedactHash:
+		sum := sha256.Sum256([]byte(rule.HashSalt + fmt.Sprint(value)))
+		digest := hex.EncodeToString(sum[:])
+		if rule.HashLength > 0 && rule.HashLength < len(digest) {
+			digest = digest[:rule.HashLength]
+		}
+		return rule.HashPrefix + digest, false
+	case RedactKeepLastN:
+		s := fmt.Sprint(value)
+		n := rule.KeepLastNChars
+		if n < 0 {
+			n = 0
+		}
+		if n >= len(s) {
+			return s, false
+		}
+		return strings.Repeat("*", len(s)-n) + s[len(s)-n:], false
+	default:
+		return value, false
+	}
+}
+
+// fieldRef is a settable location inside a parsed JSON document: either a
+// key of a map or an index of a slice.
+type fieldRef struct {
+	m  map[string]interface{}
+	mk string
+	s  []interface{}
+	sk int
+}
+
+func (r fieldRef) get() interface{} {
+	if r.m != nil {
+		return r.m[r.mk]
+	}
+	return r.s[r.sk]
+}
+
+func (r fieldRef) set(v interface{}) {
+	if r.m != nil {
+		r.m[r.mk] = v
+		return
+	}
+	r.s[r.sk] = v
+}
+
+// delete removes the field from its map, or - for a slice element, which
+// can't be removed without reindexing every later match - nils it out.
+func (r fieldRef) delete() {
+	if r.m != nil {
+		delete(r.m, r.mk)
+		return
+	}
+	r.s[r.sk] = nil
+}
+
+// splitSelector splits a selector on "." the way collectRefs expects,
+// treating "." as a separator everywhere except inside a "[...]" segment -
+// a "[?(@.a.b)]" predicate's dots are part of that segment, not boundaries
+// between selector segments.
+func splitSelector(selector string) []string {
+	var segments []string
+	depth, start := 0, 0
+	for i, c := range selector {
+		switch c {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case '.':
+			if depth == 0 {
+				segments = append(segments, selector[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(segments, selector[start:])
+}
+
+// predicateSegment matches a "name[?(@.some.path)]" selector segment: walk
+// every element of the array field "name", keeping only the elements where
+// the dotted path resolves to a present, non-null value.
+var predicateSegment = regexp.MustCompile(`^(\w*)\[\?\(@\.([\w.]+)\)\]$`)
+
+// collectRefs walks node per segments, a JSONPath-style selector already
+// split on ".", and returns a ref for every field the full path resolves
+// to. A "name[*]" segment walks every element of the array field "name"
+// ("[*]" alone walks node itself, when node is already an array); a bare
+// "*" segment walks every key of a map field; a "name[?(@.path)]" segment
+// walks every element of the array field "name" whose own "path" resolves
+// to a present, non-null value.
+func collectRefs(node interface{}, segments []string) []fieldRef {
+	if len(segments) == 0 {
+		return nil
+	}
+	segment, rest := segments[0], segments[1:]
+
+	if m := predicateSegment.FindStringSubmatch(segment); m != nil {
+		key, predicate := m[1], strings.Split(m[2], ".")
+		arr := node
+		if key != "" {
+			obj, ok := node.(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			arr = obj[key]
+		}
+		list, ok := arr.([]interface{})
+		if !ok {
+			return nil
+		}
+		var refs []fieldRef
+		for i := range list {
+			if !pathExists(list[i], predicate) {
+				continue
+			}
+			if len(rest) == 0 {
+				refs = append(refs, fieldRef{s: list, sk: i})
+			} else {
+				refs = append(refs, collectRefs(list[i], rest)...)
+			}
+		}
+		return refs
+	}
+
+	if strings.HasSuffix(segment, "[*]") {
+		key := strings.TrimSuffix(segment, "[*]")
+		arr := node
+		if key != "" {
+			m, ok := node.(map[string]interface{})
+			if !ok {
+				return nil
+			}
+			arr = m[key]
+		}
+		list, ok := arr.([]interface{})
+		if !ok {
+			return nil
+		}
+		var refs []fieldRef
+		for i := range list {
+			if len(rest) == 0 {
+				refs = append(refs, fieldRef{s: list, sk: i})
+			} else {
+				refs = append(refs, collectRefs(list[i], rest)...)
+			}
+		}
+		return refs
+	}
+
+	if segment == "*" {
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		var refs []fieldRef
+		for k := range m {
+			if len(rest) == 0 {
+				refs = append(refs, fieldRef{m: m, mk: k})
+			} else {
+				refs = append(refs, collectRefs(m[k], rest)...)
+			}
+		}
+		return refs
+	}
+
+	m, ok := node.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	if _, exists := m[segment]; !exists {
+		return nil
+	}
+	if len(rest) == 0 {
+		return []fieldRef{{m: m, mk: segment}}
+	}
+	return collectRefs(m[segment], rest)
+}
+
+// pathExists reports whether the dotted path segs, walked from node through
+// nested maps, resolves to a present, non-null value. It's used to evaluate
+// a "[?(@.path)]" predicate segment.
+func pathExists(node interface{}, segs []string) bool {
+	for _, seg := range segs {
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		v, exists := m[seg]
+		if !exists {
+			return false
+		}
+		node = v
+	}
+	return node != nil
+}
+
+// DefaultRedactionPolicy returns the redaction rules this package recommends
+// for every cluster: hashing the top-level data/stringData values of a
+// requestObject or responseObject (a Secret's, which routinely hold
+// ServiceAccount tokens, TLS keys, and kubeconfigs) and the values of
+// environment variables populated from a secretKeyRef in its top-level
+// spec.containers. Objects inside a list's items, initContainers, and pod
+// templates are not covered. HashPrefix/HashLength keep the digest
+// short enough to skim in a log line while still letting two events be
+// compared for equality without recovering the original secret.
+func DefaultRedactionPolicy() RedactionPolicy {
+	var rules []RedactionRule
+	for _, root := range []string{"requestObject", "responseObject"} {
+		rules = append(rules,
+			RedactionRule{
+				Selector:   root + ".data.*",
+				Strategy:   RedactHash,
+				HashPrefix: "sha256:",
+				HashLength: 12,
+			},
+			RedactionRule{
+				Selector:   root + ".stringData.*",
+				Strategy:   RedactHash,
+				HashPrefix: "sha256:",
+				HashLength: 12,
+			},
+			RedactionRule{
+				Selector:   root + ".spec.containers[?(@.env)].env[?(@.valueFrom.secretKeyRef)].value",
+				Strategy:   RedactHash,
+				HashPrefix: "sha256:",
+				HashLength: 12,
+			},
+		)
+	}
+	return RedactionPolicy{Rules: rules}
+}
@@ -0,0 +1,326 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package log
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	auditinternal "k8s.io/apiserver/pkg/apis/audit"
+)
+
+// podListEvent wraps the PodList-with-secret-volumes payload embedded in
+// this chunk as an event's responseObject, exercising the same kind of
+// nested, array-heavy document logEvent sees for a real deletecollection.
+func podListEvent() *auditinternal.Event {
+	return &auditinternal.Event{
+		Verb: "deletecollection",
+		ResponseObject: &runtime.Unknown{
+			Raw:         []byte(payload),
+			ContentType: runtime.ContentTypeJSON,
+		},
+	}
+}
+
+func mustLoadPolicy(t *testing.T, yamlDoc string) RedactionPolicy {
+	t.Helper()
+	policy, err := LoadRedactionPolicy([]byte(yamlDoc))
+	if err != nil {
+		t.Fatalf("LoadRedactionPolicy() error = %v", err)
+	}
+	return policy
+}
+
+func responseItems(t *testing.T, ev *auditinternal.Event) []interface{} {
+	t.Helper()
+	var parsed map[string]interface{}
+	if err := json.Unmarshal(ev.ResponseObject.Raw, &parsed); err != nil {
+		t.Fatalf("responseObject isn't valid JSON: %v", err)
+	}
+	items, _ := parsed["items"].([]interface{})
+	if len(items) == 0 {
+		t.Fatalf("responseObject has no items: %s", ev.ResponseObject.Raw)
+	}
+	return items
+}
+
+func TestRedactEventDropsAnnotationsByWildcard(t *testing.T) {
+	policy := mustLoadPolicy(t, `
+rules:
+- selector: responseObject.items[*].metadata.annotations.*
+  strategy: drop
+`)
+
+	ev := redactEvent(podListEvent(), policy)
+
+	items := responseItems(t, ev)
+	metadata := items[0].(map[string]interface{})["metadata"].(map[string]interface{})
+	annotations, _ := metadata["annotations"].(map[string]interface{})
+	if len(annotations) != 0 {
+		t.Errorf("annotations = %v, want all keys dropped", annotations)
+	}
+}
+
+func TestRedactEventMasksSecretVolumeNames(t *testing.T) {
+	policy := mustLoadPolicy(t, `
+rules:
+- selector: responseObject.items[*].spec.volumes[*].secret.secretName
+  strategy: mask
+`)
+
+	ev := redactEvent(podListEvent(), policy)
+
+	items := responseItems(t, ev)
+	volumes := items[0].(map[string]interface{})["spec"].(map[string]interface{})["volumes"].([]interface{})
+	for _, v := range volumes {
+		secret, ok := v.(map[string]interface{})["secret"].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if secret["secretName"] != "***" {
+			t.Errorf("secret.secretName = %v, want masked", secret["secretName"])
+		}
+	}
+}
+
+func TestRedactEventHashesEnvValuesMatchingRegex(t *testing.T) {
+	ev := &auditinternal.Event{
+		RequestObject: &runtime.Unknown{
+			Raw: []byte(`{
+				"spec": {"containers": [{"env": [
+					{"name": "API_TOKEN", "value": "super-secret-token"},
+					{"name": "LOG_LEVEL", "value": "debug"}
+				]}]}
+			}`),
+		},
+	}
+	policy := mustLoadPolicy(t, `
+rules:
+- selector: requestObject.spec.containers[*].env[*].value
+  valueRegex: secret
+  strategy: hash
+  hashSalt: pepper
+`)
+
+	got := redactEvent(ev, policy)
+
+	var parsed map[string]interface{}
+	if err := json.Unmarshal(got.RequestObject.Raw, &parsed); err != nil {
+		t.Fatalf("requestObject isn't valid JSON: %v", err)
+	}
+	env := parsed["spec"].(map[string]interface{})["containers"].([]interface{})[0].(map[string]interface{})["env"].([]interface{})
+
+	token := env[0].(map[string]interface{})["value"].(string)
+	if token == "super-secret-token" || len(token) != 64 {
+		t.Errorf("API_TOKEN value = %q, want a 64-char hex digest", token)
+	}
+	logLevel := env[1].(map[string]interface{})["value"]
+	if logLevel != "debug" {
+		t.Errorf("LOG_LEVEL value = %v, want untouched because it doesn't match valueRegex", logLevel)
+	}
+}
+
+func TestRedactEventKeepLastNChars(t *testing.T) {
+	ev := &auditinternal.Event{
+		RequestObject: &runtime.Unknown{Raw: []byte(`{"data": {"password": "hunter2345"}}`)},
+	}
+	policy := mustLoadPolicy(t, `
+rules:
+- selector: requestObject.data.*
+  strategy: keep-last-n
+  keepLastNChars: 4
+`)
+
+	got := redactEvent(ev, policy)
+
+	var parsed map[string]interface{}
+	if err := json.Unmarshal(got.RequestObject.Raw, &parsed); err != nil {
+		t.Fatalf("requestObject isn't valid JSON: %v", err)
+	}
+	password := parsed["data"].(map[string]interface{})["password"].(string)
+	if password != "******2345" {
+		t.Errorf("password = %q, want last 4 chars kept and the rest masked", password)
+	}
+}
+
+func TestRedactEventLeavesIdentifyingFieldsIntact(t *testing.T) {
+	policy := mustLoadPolicy(t, `
+rules:
+- selector: responseObject.items[*].metadata.annotations.*
+  strategy: drop
+`)
+
+	ev := podListEvent()
+	ev.AuditID = "36384c8a-1394-4c92-8726-904e4c442b7d"
+	ev.Verb = "deletecollection"
+	ev.ObjectRef = &auditinternal.ObjectReference{Resource: "pods"}
+
+	got := redactEvent(ev, policy)
+
+	if got.AuditID != ev.AuditID || got.Verb != ev.Verb || got.ObjectRef.Resource != "pods" {
+		t.Errorf("identifying fields changed: auditID=%q verb=%q resource=%q", got.AuditID, got.Verb, got.ObjectRef.Resource)
+	}
+}
+
+func TestRedactEventNoRulesIsNoop(t *testing.T) {
+	ev := podListEvent()
+	got := redactEvent(ev, RedactionPolicy{})
+	if got != ev {
+		t.Errorf("redactEvent with no rules should return the same event pointer unchanged")
+	}
+}
+
+func TestLoadRedactionPolicyRejectsUnknownStrategy(t *testing.T) {
+	_, err := LoadRedactionPolicy([]byte(`
+rules:
+- selector: requestObject.data.*
+  strategy: shred
+`))
+	if err == nil || !strings.Contains(err.Error(), "unknown strategy") {
+		t.Errorf("LoadRedactionPolicy() error = %v, want an unknown strategy error", err)
+	}
+}
+
+func TestLoadRedactionPolicyRejectsInvalidRegex(t *testing.T) {
+	_, err := LoadRedactionPolicy([]byte(`
+rules:
+- selector: requestObject.data.*
+  valueRegex: "("
+  strategy: mask
+`))
+	if err == nil {
+		t.Error("LoadRedactionPolicy() error = nil, want an error for the invalid valueRegex")
+	}
+}
+
+func TestRedactEventHashPrefixAndLengthTruncateDigest(t *testing.T) {
+	ev := &auditinternal.Event{
+		RequestObject: &runtime.Unknown{Raw: []byte(`{"data": {"token": "hunter2345"}}`)},
+	}
+	policy := mustLoadPolicy(t, `
+rules:
+- selector: requestObject.data.*
+  strategy: hash
+  hashPrefix: "sha256:"
+  hashLength: 12
+`)
+
+	got := redactEvent(ev, policy)
+
+	var parsed map[string]interface{}
+	if err := json.Unmarshal(got.RequestObject.Raw, &parsed); err != nil {
+		t.Fatalf("requestObject isn't valid JSON: %v", err)
+	}
+	token := parsed["data"].(map[string]interface{})["token"].(string)
+	if !strings.HasPrefix(token, "sha256:") || len(token) != len("sha256:")+12 {
+		t.Errorf("token = %q, want \"sha256:\" plus a 12-char digest", token)
+	}
+}
+
+// envWithValueFromEvent builds a requestObject whose shape mirrors the
+// payload fixture's containers, but with an env entry sourced from a
+// Secret - the payload fixture has no such field to redact in place.
+func envWithValueFromEvent() *auditinternal.Event {
+	return &auditinternal.Event{
+		RequestObject: &runtime.Unknown{
+			Raw: []byte(`{
+				"spec": {"containers": [
+					{"name": "app", "env": [
+						{"name": "LOG_LEVEL", "value": "debug"},
+						{"name": "DB_PASSWORD", "value": "hunter2345", "valueFrom": {"secretKeyRef": {"name": "db", "key": "password"}}}
+					]},
+					{"name": "sidecar"}
+				]}
+			}`),
+		},
+	}
+}
+
+func TestRedactEventPredicateOnlyMatchesEnvValuesSourcedFromSecrets(t *testing.T) {
+	policy := mustLoadPolicy(t, `
+rules:
+- selector: requestObject.spec.containers[?(@.env)].env[?(@.valueFrom.secretKeyRef)].value
+  strategy: mask
+`)
+
+	got := redactEvent(envWithValueFromEvent(), policy)
+
+	var parsed map[string]interface{}
+	if err := json.Unmarshal(got.RequestObject.Raw, &parsed); err != nil {
+		t.Fatalf("requestObject isn't valid JSON: %v", err)
+	}
+	env := parsed["spec"].(map[string]interface{})["containers"].([]interface{})[0].(map[string]interface{})["env"].([]interface{})
+	if env[0].(map[string]interface{})["value"] != "debug" {
+		t.Errorf("LOG_LEVEL value = %v, want untouched because it has no valueFrom.secretKeyRef", env[0].(map[string]interface{})["value"])
+	}
+	if env[1].(map[string]interface{})["value"] != "***" {
+		t.Errorf("DB_PASSWORD value = %v, want masked because it has valueFrom.secretKeyRef", env[1].(map[string]interface{})["value"])
+	}
+}
+
+func TestDefaultRedactionPolicyHashesSecretDataAndLeavesStructureIntact(t *testing.T) {
+	ev := &auditinternal.Event{
+		RequestObject: &runtime.Unknown{
+			Raw: []byte(`{
+				"kind": "Secret",
+				"metadata": {"name": "db-creds", "namespace": "default"},
+				"data": {"password": "aHVudGVyMjM0NQ=="},
+				"stringData": {"note": "plaintext"}
+			}`),
+		},
+	}
+
+	got := redactEvent(ev, DefaultRedactionPolicy())
+
+	var parsed map[string]interface{}
+	if err := json.Unmarshal(got.RequestObject.Raw, &parsed); err != nil {
+		t.Fatalf("requestObject isn't valid JSON: %v", err)
+	}
+	if parsed["metadata"].(map[string]interface{})["name"] != "db-creds" {
+		t.Errorf("metadata.name changed, want untouched")
+	}
+	password := parsed["data"].(map[string]interface{})["password"]
+	if password == "aHVudGVyMjM0NQ==" {
+		t.Errorf("data.password = %v, want hashed", password)
+	}
+	note := parsed["stringData"].(map[string]interface{})["note"]
+	if note == "plaintext" {
+		t.Errorf("stringData.note = %v, want hashed", note)
+	}
+}
+
+func TestDefaultRedactionPolicyOnlyTouchesSecretSourcedEnvValues(t *testing.T) {
+	got := redactEvent(envWithValueFromEvent(), DefaultRedactionPolicy())
+
+	var parsed map[string]interface{}
+	if err := json.Unmarshal(got.RequestObject.Raw, &parsed); err != nil {
+		t.Fatalf("requestObject isn't valid JSON: %v", err)
+	}
+	containers := parsed["spec"].(map[string]interface{})["containers"].([]interface{})
+	env := containers[0].(map[string]interface{})["env"].([]interface{})
+	if env[0].(map[string]interface{})["value"] != "debug" {
+		t.Errorf("LOG_LEVEL value = %v, want untouched", env[0].(map[string]interface{})["value"])
+	}
+	if env[1].(map[string]interface{})["value"] == "hunter2345" {
+		t.Error("DB_PASSWORD value wasn't redacted by the default policy")
+	}
+	if len(containers) != 2 || containers[1].(map[string]interface{})["name"] != "sidecar" {
+		t.Errorf("containers = %v, want the env-less sidecar container left in place", containers)
+	}
+}
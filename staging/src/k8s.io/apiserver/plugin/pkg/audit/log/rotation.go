@@ -0,0 +1,126 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package log
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	auditinternal "k8s.io/apiserver/pkg/apis/audit"
+	"k8s.io/apiserver/pkg/audit"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// truncatedAnnotationKey marks an event whose requestObject/responseObject
+// was replaced with a placeholder because it exceeded TruncationPolicy's
+// cap, so a reader of the log can tell "this object is genuinely small"
+// apart from "this object was cut down to size".
+const truncatedAnnotationKey = "audit.k8s.io/truncated"
+
+// RotationPolicy configures on-disk rotation for NewBackendFromFile,
+// mirroring the apiserver's --audit-log-max{size,age,backup} flags and
+// --audit-log-compress.
+type RotationPolicy struct {
+	// MaxSizeMB is the largest size in megabytes the log file is allowed
+	// to reach before it gets rotated. Zero means no size-based rotation.
+	MaxSizeMB int
+	// MaxAgeDays is the maximum number of days to retain old rotated log
+	// files, by the timestamp encoded in their filename. Zero means files
+	// are retained regardless of age.
+	MaxAgeDays int
+	// MaxBackups is the maximum number of old rotated log files to
+	// retain. Zero means retain all of them.
+	MaxBackups int
+	// Compress gzip-compresses rotated segments once they age out of
+	// being the active log file.
+	Compress bool
+}
+
+// TruncationPolicy caps how large a single event's requestObject/
+// responseObject may be before logEvent replaces them with a placeholder,
+// so one outsized response body (a PodList spanning an entire namespace,
+// say) can't balloon an otherwise line-oriented audit log.
+type TruncationPolicy struct {
+	// MaxObjectBytes is the largest requestObject/responseObject raw
+	// payload logEvent will emit verbatim. Zero disables truncation.
+	MaxObjectBytes int
+}
+
+// NewBackendFromFile returns a log-format audit backend that writes to
+// path, rotating it per rotation. Events are dropped by filter, then
+// sampled per sampling, then redacted per redaction and truncated per
+// truncation before they're encoded.
+func NewBackendFromFile(path, format string, groupVersion schema.GroupVersion, rotation RotationPolicy, truncation TruncationPolicy, redaction RedactionPolicy, filter FilterPolicy, sampling SamplingPolicy) audit.Backend {
+	out := &lumberjack.Logger{
+		Filename:   path,
+		MaxSize:    rotation.MaxSizeMB,
+		MaxAge:     rotation.MaxAgeDays,
+		MaxBackups: rotation.MaxBackups,
+		Compress:   rotation.Compress,
+	}
+
+	b := NewBackend(out, format, groupVersion).(*backend)
+	b.truncation = truncation
+	b.redaction = redaction
+	b.filter = filter
+	b.sampler = NewSampler(sampling)
+	return b
+}
+
+// truncateOversizedFields returns ev unchanged if neither RequestObject nor
+// ResponseObject exceeds policy.MaxObjectBytes (or policy disables
+// truncation entirely). Otherwise it returns a shallow copy of ev with the
+// oversized field(s) replaced by a placeholder and truncatedAnnotationKey
+// set, leaving the caller's original event - which other backends in the
+// same union may also be processing - untouched.
+func truncateOversizedFields(ev *auditinternal.Event, policy TruncationPolicy) *auditinternal.Event {
+	if policy.MaxObjectBytes <= 0 {
+		return ev
+	}
+
+	req, reqTruncated := truncateObject(ev.RequestObject, policy.MaxObjectBytes)
+	resp, respTruncated := truncateObject(ev.ResponseObject, policy.MaxObjectBytes)
+	if !reqTruncated && !respTruncated {
+		return ev
+	}
+
+	out := *ev
+	out.RequestObject = req
+	out.ResponseObject = resp
+	out.Annotations = make(map[string]string, len(ev.Annotations)+1)
+	for k, v := range ev.Annotations {
+		out.Annotations[k] = v
+	}
+	out.Annotations[truncatedAnnotationKey] = "true"
+	return &out
+}
+
+// truncateObject replaces obj with a small placeholder object when its raw
+// payload exceeds maxBytes, reporting whether it did so.
+func truncateObject(obj *runtime.Unknown, maxBytes int) (*runtime.Unknown, bool) {
+	if obj == nil || len(obj.Raw) <= maxBytes {
+		return obj, false
+	}
+	placeholder := *obj
+	placeholder.Raw = []byte(fmt.Sprintf(
+		`{"kind":"Truncated","apiVersion":"audit.k8s.io/v1","originalSizeBytes":%d,"reason":"exceeded the audit log backend's per-event size cap"}`,
+		len(obj.Raw),
+	))
+	return &placeholder, true
+}
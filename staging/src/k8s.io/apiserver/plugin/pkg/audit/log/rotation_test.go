@@ -0,0 +1,112 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package log
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	auditinternal "k8s.io/apiserver/pkg/apis/audit"
+	auditv1beta1 "k8s.io/apiserver/pkg/apis/audit/v1beta1"
+)
+
+// bigResponseEvent returns an event whose ResponseObject is the large
+// synthetic PodList embedded as payload in backend_test.go, the same
+// kind of outsized requestObject/responseObject TruncationPolicy exists
+// to cap.
+func bigResponseEvent() *auditinternal.Event {
+	return &auditinternal.Event{
+		AuditID: types.UID("11111111-1111-1111-1111-111111111111"),
+		Verb:    "deletecollection",
+		ResponseObject: &runtime.Unknown{
+			Raw:         []byte(payload),
+			ContentType: runtime.ContentTypeJSON,
+		},
+	}
+}
+
+func TestTruncateOversizedFieldsLeavesSmallEventsAlone(t *testing.T) {
+	ev := &auditinternal.Event{
+		AuditID:       types.UID("small"),
+		RequestObject: &runtime.Unknown{Raw: []byte(`{"kind":"DeleteOptions"}`)},
+	}
+	got := truncateOversizedFields(ev, TruncationPolicy{MaxObjectBytes: len(payload)})
+	if got != ev {
+		t.Fatalf("truncateOversizedFields() returned a copy for an event under the size cap")
+	}
+}
+
+func TestTruncateOversizedFieldsCapsLargeResponseObject(t *testing.T) {
+	ev := bigResponseEvent()
+	originalLen := len(ev.ResponseObject.Raw)
+
+	got := truncateOversizedFields(ev, TruncationPolicy{MaxObjectBytes: 1024})
+
+	if got == ev {
+		t.Fatalf("truncateOversizedFields() did not replace an oversized responseObject")
+	}
+	if len(ev.ResponseObject.Raw) != originalLen {
+		t.Errorf("original event's responseObject was mutated; truncation must not affect the caller's event")
+	}
+	if len(got.ResponseObject.Raw) >= originalLen {
+		t.Errorf("truncated responseObject is %d bytes, want far less than the original %d", len(got.ResponseObject.Raw), originalLen)
+	}
+	if got.Annotations[truncatedAnnotationKey] != "true" {
+		t.Errorf("annotations = %v, want %s=true", got.Annotations, truncatedAnnotationKey)
+	}
+}
+
+func TestTruncateOversizedFieldsDisabledByZeroCap(t *testing.T) {
+	ev := bigResponseEvent()
+	got := truncateOversizedFields(ev, TruncationPolicy{})
+	if got != ev {
+		t.Fatalf("truncateOversizedFields() with MaxObjectBytes=0 should be a no-op")
+	}
+}
+
+// TestLogEventsJsonWithTruncation drives a large synthetic event (the
+// embedded PodList payload) through the same JSON-format path
+// TestLogEventsJson exercises, but via a backend configured with a
+// TruncationPolicy tight enough to force truncation, verifying that the
+// emitted line stays small and carries the truncation marker rather than
+// the full PodList.
+func TestLogEventsJsonWithTruncation(t *testing.T) {
+	var buf bytes.Buffer
+	b := NewBackend(&buf, FormatJson, auditv1beta1.SchemeGroupVersion).(*backend)
+	b.truncation = TruncationPolicy{MaxObjectBytes: 1024}
+
+	ev := bigResponseEvent()
+	if !b.ProcessEvents(ev) {
+		t.Fatalf("ProcessEvents() = false, want true")
+	}
+
+	line := buf.String()
+	if len(line) >= len(payload) {
+		t.Errorf("logged line is %d bytes, want far less than the untruncated payload's %d bytes", len(line), len(payload))
+	}
+	if !strings.Contains(line, truncatedAnnotationKey) {
+		t.Errorf("logged line missing %s annotation: %s", truncatedAnnotationKey, line)
+	}
+	// The original event handed to ProcessEvents must come out unmodified,
+	// since other backends in the same union may process the same pointer.
+	if len(ev.ResponseObject.Raw) != len(payload) {
+		t.Errorf("ProcessEvents mutated the caller's event in place")
+	}
+}
@@ -0,0 +1,376 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package log
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	auditinternal "k8s.io/apiserver/pkg/apis/audit"
+	"k8s.io/apiserver/pkg/audit"
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+	"k8s.io/klog/v2"
+)
+
+// SyslogFacility is an RFC5424 facility code (RFC 5424 section 6.2.1).
+type SyslogFacility int
+
+const (
+	FacilityKern     SyslogFacility = 0
+	FacilityUser     SyslogFacility = 1
+	FacilityDaemon   SyslogFacility = 3
+	FacilityAuthpriv SyslogFacility = 10
+	FacilityLocal0   SyslogFacility = 16
+	FacilityLocal1   SyslogFacility = 17
+	FacilityLocal2   SyslogFacility = 18
+	FacilityLocal3   SyslogFacility = 19
+	FacilityLocal4   SyslogFacility = 20
+	FacilityLocal5   SyslogFacility = 21
+	FacilityLocal6   SyslogFacility = 22
+	FacilityLocal7   SyslogFacility = 23
+)
+
+// syslogSeverity is fixed at "informational" (6): audit events carry no
+// severity of their own, and "info" is what the rest of the apiserver's
+// syslog output already uses.
+const syslogSeverity = 6
+
+// syslogEnterpriseID is the SD-ID enterprise number this backend's
+// structured-data element is registered under, so "[k8s@41058 ...]" can't
+// collide with another vendor's structured data on the same line.
+const syslogEnterpriseID = 41058
+
+// syslogDefaultBufferSize is how many formatted lines NewSyslogBackend
+// queues while disconnected before it starts dropping the oldest ones.
+const syslogDefaultBufferSize = 1000
+
+var (
+	syslogEventsDropped = metrics.NewCounterVec(
+		&metrics.CounterOpts{
+			Subsystem:      "audit",
+			Name:           "syslog_backend_dropped_events_total",
+			Help:           "Number of audit events dropped by the syslog backend's bounded buffer because the receiver couldn't keep up, partitioned by destination address.",
+			StabilityLevel: metrics.ALPHA,
+		},
+		[]string{"addr"},
+	)
+	registerSyslogMetricsOnce sync.Once
+)
+
+func registerSyslogMetrics() {
+	registerSyslogMetricsOnce.Do(func() {
+		legacyregistry.MustRegister(syslogEventsDropped)
+	})
+}
+
+// SyslogOpts configures NewSyslogBackend.
+type SyslogOpts struct {
+	// Network is the address family/protocol to dial: "udp", "tcp", or
+	// "tcp-tls" for syslog over TLS.
+	Network string
+	// Addr is the syslog receiver's host:port.
+	Addr string
+	// Facility is the RFC5424 facility code written into PRI.
+	Facility SyslogFacility
+	// AppName is RFC5424's APP-NAME field, identifying this apiserver in
+	// the receiver's logs. Empty is sent as "-".
+	AppName string
+	// Format selects the Formatter that renders MSG: one of FormatLegacy,
+	// FormatJson, or FormatECS.
+	Format string
+	// BufferSize caps how many formatted lines are queued while
+	// disconnected. Once full, ProcessEvents drops the oldest queued line
+	// rather than blocking. Zero defaults to syslogDefaultBufferSize.
+	BufferSize int
+	// TLSConfig is used to dial when Network is "tcp-tls". Ignored
+	// otherwise.
+	TLSConfig *tls.Config
+}
+
+// syslogBackend is a sibling of backend that ships events to an RFC5424
+// syslog receiver instead of a local file, sharing the same Formatter
+// pipeline for MSG. Unlike backend, writes happen on a background
+// goroutine: ProcessEvents only ever enqueues, so a slow or unreachable
+// receiver can't block the caller.
+type syslogBackend struct {
+	network   string
+	addr      string
+	facility  SyslogFacility
+	appName   string
+	format    string
+	encoder   runtime.Encoder
+	tlsConfig *tls.Config
+	hostname  string
+
+	bufferSize int
+
+	mu    sync.Mutex
+	conn  net.Conn
+	queue [][]byte
+
+	notify chan struct{}
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+var _ audit.Backend = &syslogBackend{}
+
+// NewSyslogBackend returns an audit backend that renders events with the
+// Formatter for opts.Format and ships them to opts.Addr as RFC5424 syslog
+// messages, reconnecting with exponential backoff when opts.Network is
+// "tcp"/"tcp-tls" and the connection drops.
+func NewSyslogBackend(groupVersion schema.GroupVersion, opts SyslogOpts) (audit.Backend, error) {
+	switch opts.Format {
+	case FormatLegacy, FormatJson, FormatECS:
+	default:
+		return nil, fmt.Errorf("syslog backend does not support format %q, must be one of [%s %s %s]", opts.Format, FormatLegacy, FormatJson, FormatECS)
+	}
+
+	bufferSize := opts.BufferSize
+	if bufferSize <= 0 {
+		bufferSize = syslogDefaultBufferSize
+	}
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+
+	registerSyslogMetrics()
+	return &syslogBackend{
+		network:    opts.Network,
+		addr:       opts.Addr,
+		facility:   opts.Facility,
+		appName:    opts.AppName,
+		format:     opts.Format,
+		encoder:    audit.Codecs.LegacyCodec(groupVersion),
+		tlsConfig:  opts.TLSConfig,
+		hostname:   hostname,
+		bufferSize: bufferSize,
+		notify:     make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}, nil
+}
+
+// ProcessEvents formats and enqueues ev for the background sender goroutine
+// started by Run. It never blocks on the network: once the queue reaches
+// BufferSize, the oldest queued line is dropped to make room.
+func (b *syslogBackend) ProcessEvents(events ...*auditinternal.Event) bool {
+	formatter, err := newFormatter(b.format, b.encoder)
+	if err != nil {
+		klog.ErrorS(err, "Unable to select syslog formatter")
+		return false
+	}
+
+	for _, ev := range events {
+		msg, err := formatter.Format(ev)
+		if err != nil {
+			klog.ErrorS(err, "Unable to encode audit event for syslog")
+			continue
+		}
+		b.enqueue(b.syslogLine(ev, bytes.TrimRight(msg, "\n")))
+	}
+	return true
+}
+
+// enqueue appends line to the buffer, dropping the oldest queued line and
+// counting it in syslogEventsDropped if the buffer is already full.
+func (b *syslogBackend) enqueue(line []byte) {
+	b.mu.Lock()
+	if len(b.queue) >= b.bufferSize {
+		b.queue = b.queue[1:]
+		syslogEventsDropped.WithLabelValues(b.addr).Inc()
+	}
+	b.queue = append(b.queue, line)
+	b.mu.Unlock()
+
+	select {
+	case b.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (b *syslogBackend) dequeueAll() [][]byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.queue) == 0 {
+		return nil
+	}
+	lines := b.queue
+	b.queue = nil
+	return lines
+}
+
+// Run starts the background goroutine that drains the queue to the syslog
+// connection, reconnecting as needed, until stopCh is closed or Shutdown is
+// called.
+func (b *syslogBackend) Run(stopCh <-chan struct{}) error {
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		b.runLoop(stopCh)
+	}()
+	return nil
+}
+
+func (b *syslogBackend) runLoop(stopCh <-chan struct{}) {
+	for {
+		select {
+		case <-stopCh:
+			return
+		case <-b.done:
+			return
+		case <-b.notify:
+		}
+		for _, line := range b.dequeueAll() {
+			b.writeLine(line, stopCh)
+		}
+	}
+}
+
+// writeLine writes line to the syslog connection, (re)connecting with
+// exponential backoff as needed, until it succeeds or stopCh/done fires.
+func (b *syslogBackend) writeLine(line []byte, stopCh <-chan struct{}) {
+	for {
+		conn := b.ensureConn(stopCh)
+		if conn == nil {
+			return // Run is stopping.
+		}
+		if _, err := conn.Write(line); err != nil {
+			klog.ErrorS(err, "Syslog backend write failed, reconnecting", "addr", b.addr)
+			b.closeConn(conn)
+			continue
+		}
+		return
+	}
+}
+
+// ensureConn returns the current connection, dialing a new one with
+// exponential backoff (capped at 30s) if there isn't one. It returns nil
+// only when stopCh or done fires while waiting to redial.
+func (b *syslogBackend) ensureConn(stopCh <-chan struct{}) net.Conn {
+	b.mu.Lock()
+	conn := b.conn
+	b.mu.Unlock()
+	if conn != nil {
+		return conn
+	}
+
+	backoff := time.Second
+	for {
+		conn, err := b.dial()
+		if err == nil {
+			b.mu.Lock()
+			b.conn = conn
+			b.mu.Unlock()
+			return conn
+		}
+		klog.ErrorS(err, "Syslog backend connect failed, retrying", "addr", b.addr, "backoff", backoff)
+
+		select {
+		case <-stopCh:
+			return nil
+		case <-b.done:
+			return nil
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > 30*time.Second {
+			backoff = 30 * time.Second
+		}
+	}
+}
+
+func (b *syslogBackend) dial() (net.Conn, error) {
+	if b.network == "tcp-tls" {
+		return tls.Dial("tcp", b.addr, b.tlsConfig)
+	}
+	return net.Dial(b.network, b.addr)
+}
+
+// closeConn closes conn and clears it from b.conn, but only if it's still
+// the current connection - a concurrent reconnect may have already
+// replaced it.
+func (b *syslogBackend) closeConn(conn net.Conn) {
+	conn.Close()
+	b.mu.Lock()
+	if b.conn == conn {
+		b.conn = nil
+	}
+	b.mu.Unlock()
+}
+
+// syslogLine renders ev as a complete RFC5424 message, newline-terminated
+// for receivers using non-transparent (LF) framing.
+func (b *syslogBackend) syslogLine(ev *auditinternal.Event, msg []byte) []byte {
+	pri := int(b.facility)*8 + syslogSeverity
+	appName := b.appName
+	if appName == "" {
+		appName = "-"
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "<%d>1 %s %s %s - - %s %s\n",
+		pri, ev.Timestamp.Format(time.RFC3339Nano), b.hostname, appName, syslogStructuredData(ev), msg)
+	return buf.Bytes()
+}
+
+// syslogStructuredData renders ev's structured-data element,
+// "[k8s@41058 auditID=\"...\" stage=\"...\" verb=\"...\" user=\"...\" namespace=\"...\"]".
+func syslogStructuredData(ev *auditinternal.Event) string {
+	var namespace string
+	if ev.ObjectRef != nil {
+		namespace = ev.ObjectRef.Namespace
+	}
+	return fmt.Sprintf("[k8s@%d auditID=%s stage=%s verb=%s user=%s namespace=%s]",
+		syslogEnterpriseID,
+		sdParamValue(string(ev.AuditID)),
+		sdParamValue(string(ev.Stage)),
+		sdParamValue(ev.Verb),
+		sdParamValue(ev.User.Username),
+		sdParamValue(namespace),
+	)
+}
+
+// sdParamValue quotes s as an RFC5424 PARAM-VALUE, backslash-escaping the
+// three characters (\, ", ]) the spec requires escaped inside one.
+var sdParamEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+func sdParamValue(s string) string {
+	return `"` + sdParamEscaper.Replace(s) + `"`
+}
+
+func (b *syslogBackend) Shutdown() {
+	close(b.done)
+	b.wg.Wait()
+	b.mu.Lock()
+	if b.conn != nil {
+		b.conn.Close()
+		b.conn = nil
+	}
+	b.mu.Unlock()
+}
+
+func (b *syslogBackend) String() string {
+	return fmt.Sprintf("syslog:%s:%s", b.network, b.addr)
+}
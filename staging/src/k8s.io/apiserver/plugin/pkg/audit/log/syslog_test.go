@@ -0,0 +1,153 @@
+/*
+Copyright 2025 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package log
+
+import (
+	"bufio"
+	"net"
+	"regexp"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	auditinternal "k8s.io/apiserver/pkg/apis/audit"
+	auditv1beta1 "k8s.io/apiserver/pkg/apis/audit/v1beta1"
+)
+
+// syslogFixtureEvents mirrors the first three events TestLogEventsLegacy
+// exercises, without the second one's responseObject: an empty event, a get
+// by admin in the default namespace, and a metadata-level event with no
+// request/response bodies.
+func syslogFixtureEvents() []*auditinternal.Event {
+	return []*auditinternal.Event{
+		{
+			AuditID: types.UID("11111111-1111-1111-1111-111111111111"),
+		},
+		{
+			ResponseStatus: &metav1.Status{Code: 200},
+			RequestURI:     "/apis/rbac.authorization.k8s.io/v1/roles",
+			SourceIPs:      []string{"127.0.0.1"},
+			Timestamp:      metav1.NewTime(time.Now()),
+			AuditID:        types.UID("22222222-2222-2222-2222-222222222222"),
+			Stage:          auditinternal.StageRequestReceived,
+			Verb:           "get",
+			User: auditinternal.UserInfo{
+				Username: "admin",
+				Groups:   []string{"system:masters", "system:authenticated"},
+			},
+			ObjectRef: &auditinternal.ObjectReference{Namespace: "default"},
+		},
+		{
+			AuditID: types.UID("33333333-3333-3333-3333-333333333333"),
+			Level:   auditinternal.LevelMetadata,
+			ObjectRef: &auditinternal.ObjectReference{
+				Resource:    "foo",
+				APIVersion:  "v1",
+				Subresource: "bar",
+			},
+		},
+	}
+}
+
+var sdParamPattern = regexp.MustCompile(`\[k8s@41058 auditID="([^"]*)" stage="([^"]*)" verb="([^"]*)" user="([^"]*)" namespace="([^"]*)"\]`)
+
+func TestSyslogBackendSendsParsableStructuredData(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("net.Listen() error = %v", err)
+	}
+	defer ln.Close()
+
+	lines := make(chan string, len(syslogFixtureEvents()))
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	backend, err := NewSyslogBackend(auditv1beta1.SchemeGroupVersion, SyslogOpts{
+		Network: "tcp",
+		Addr:    ln.Addr().String(),
+		AppName: "kube-apiserver",
+		Format:  FormatLegacy,
+	})
+	if err != nil {
+		t.Fatalf("NewSyslogBackend() error = %v", err)
+	}
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	if err := backend.Run(stopCh); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	defer backend.Shutdown()
+
+	for _, ev := range syslogFixtureEvents() {
+		backend.ProcessEvents(ev)
+
+		var line string
+		select {
+		case line = <-lines:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for event %s to reach the syslog listener", ev.AuditID)
+		}
+
+		m := sdParamPattern.FindStringSubmatch(line)
+		if m == nil {
+			t.Fatalf("line %q has no parsable [k8s@41058 ...] structured-data element", line)
+		}
+		auditID, stage, verb, user, namespace := m[1], m[2], m[3], m[4], m[5]
+
+		if auditID != string(ev.AuditID) {
+			t.Errorf("SD-PARAM auditID = %q, want %q", auditID, ev.AuditID)
+		}
+		if stage != string(ev.Stage) {
+			t.Errorf("SD-PARAM stage = %q, want %q", stage, ev.Stage)
+		}
+		if verb != ev.Verb {
+			t.Errorf("SD-PARAM verb = %q, want %q", verb, ev.Verb)
+		}
+		if user != ev.User.Username {
+			t.Errorf("SD-PARAM user = %q, want %q", user, ev.User.Username)
+		}
+		wantNamespace := ""
+		if ev.ObjectRef != nil {
+			wantNamespace = ev.ObjectRef.Namespace
+		}
+		if namespace != wantNamespace {
+			t.Errorf("SD-PARAM namespace = %q, want %q", namespace, wantNamespace)
+		}
+	}
+}
+
+func TestNewSyslogBackendRejectsUnknownFormat(t *testing.T) {
+	_, err := NewSyslogBackend(auditv1beta1.SchemeGroupVersion, SyslogOpts{
+		Network: "tcp",
+		Addr:    "127.0.0.1:0",
+		Format:  "bogus",
+	})
+	if err == nil {
+		t.Error("NewSyslogBackend() error = nil, want an error for the unsupported format")
+	}
+}